@@ -1,7 +1,15 @@
 package feishu
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/lynnclub/go/v1/encoding/json"
@@ -13,21 +21,115 @@ import (
 type GroupRobot struct {
 	Webhook string // 飞书机器人Webhook地址
 	SignKey string // 机器人签名密钥，用于验证消息来源
+
+	client        *fasthttp.Client // HTTP客户端，默认使用fasthttp全局客户端
+	timeout       time.Duration    // 单次请求超时时间，默认3秒
+	retry         int              // 失败重试次数，默认不重试
+	retryInterval time.Duration    // 首次重试间隔，默认200毫秒，之后逐次递增
+	proxy         string           // 代理地址，所有选项执行后应用到客户端
+	tlsConfig     *tls.Config      // TLS配置，所有选项执行后应用到客户端
+	offset        atomic.Int64     // 本地时钟与飞书服务器的偏差，单位秒
 }
 
 // NewGroupRobot 创建群机器人客户端
-func NewGroupRobot(webhook, signKey string) *GroupRobot {
-	return &GroupRobot{
-		Webhook: webhook,
-		SignKey: signKey,
+func NewGroupRobot(webhook, signKey string, options ...GroupRobotOption) *GroupRobot {
+	robot := &GroupRobot{
+		Webhook:       webhook,
+		SignKey:       signKey,
+		timeout:       3 * time.Second,
+		retryInterval: 200 * time.Millisecond,
+	}
+
+	for _, option := range options {
+		option(robot)
 	}
+	robot.applyClient()
+
+	return robot
 }
 
 // SendRaw 发送原始参数到飞书API
 func (robot *GroupRobot) SendRaw(params interface{}) (response GroupRobotResponse, err error) {
+	return robot.SendRawContext(context.Background(), params)
+}
+
+// SendRawContext 发送原始参数到飞书API，支持取消
+func (robot *GroupRobot) SendRawContext(ctx context.Context, params interface{}) (response GroupRobotResponse, err error) {
+	response, _, err = robot.send(ctx, params)
+	return response, err
+}
+
+// send 发送请求，连接失败与 429、5xx 时重试，同时返回飞书服务器时间
+func (robot *GroupRobot) send(ctx context.Context, params interface{}) (response GroupRobotResponse, serverTime time.Time, err error) {
 	// 序列化请求参数
 	bodyBytes := json.EncodeToByte(params)
 
+	var body []byte
+	for attempt := 0; ; attempt++ {
+		body, serverTime, err = robot.post(ctx, bodyBytes)
+		if err == nil || attempt >= robot.retry || !isTransient(err) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return response, serverTime, ctx.Err()
+		case <-time.After(robot.retryInterval * time.Duration(attempt+1)):
+		}
+	}
+	if err != nil {
+		return response, serverTime, err
+	}
+
+	// 解析响应
+	if err = json.DecodeFromByte(body, &response); err != nil {
+		return response, serverTime, err
+	}
+
+	if response.Code != 0 {
+		return response, serverTime, errors.New(response.Msg)
+	}
+
+	return response, serverTime, nil
+}
+
+// post 单次请求，超时取ctx截止时间与timeout中较早者
+func (robot *GroupRobot) post(ctx context.Context, bodyBytes []byte) (body []byte, serverTime time.Time, err error) {
+	if err = ctx.Err(); err != nil {
+		return nil, serverTime, err
+	}
+
+	deadline := time.Now().Add(robot.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	if ctx.Done() == nil {
+		return robot.do(bodyBytes, deadline)
+	}
+
+	// fasthttp不支持context，在协程中请求，取消时不再等待
+	type result struct {
+		body       []byte
+		serverTime time.Time
+		err        error
+	}
+	done := make(chan result, 1)
+	go func() {
+		body, serverTime, err := robot.do(bodyBytes, deadline)
+		done <- result{body, serverTime, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, serverTime, ctx.Err()
+	case res := <-done:
+		return res.body, res.serverTime, res.err
+	}
+}
+
+// do 执行请求，返回响应体与服务器时间
+func (robot *GroupRobot) do(bodyBytes []byte, deadline time.Time) (body []byte, serverTime time.Time, err error) {
 	// 创建请求和响应对象
 	req := fasthttp.AcquireRequest()
 	resp := fasthttp.AcquireResponse()
@@ -41,34 +143,88 @@ func (robot *GroupRobot) SendRaw(params interface{}) (response GroupRobotRespons
 	req.SetBody(bodyBytes)
 
 	// 发送请求
-	if err = fasthttp.DoTimeout(req, resp, 3*time.Second); err != nil {
-		return response, err
+	if robot.client == nil {
+		err = fasthttp.DoDeadline(req, resp, deadline)
+	} else {
+		err = robot.client.DoDeadline(req, resp, deadline)
+	}
+	if err != nil {
+		return nil, serverTime, err
 	}
 
-	// 解析响应
-	if err = json.Decode(string(resp.Body()), &response); err != nil {
-		return response, err
+	// 服务器时间，用于校正时钟偏差
+	if date := resp.Header.Peek(fasthttp.HeaderDate); len(date) > 0 {
+		serverTime, _ = http.ParseTime(string(date))
 	}
 
-	if response.Code != 0 {
-		return response, errors.New(response.Msg)
+	if resp.StatusCode() == fasthttp.StatusTooManyRequests || resp.StatusCode() >= fasthttp.StatusInternalServerError {
+		return nil, serverTime, &statusError{resp.StatusCode()}
 	}
 
-	return response, nil
+	return append([]byte(nil), resp.Body()...), serverTime, nil
 }
 
 // Send 发送消息
 func (robot *GroupRobot) Send(request *GroupRobotRequest) (response GroupRobotResponse, err error) {
-	if robot.SignKey != "" {
-		signValue, err := sign.FeiShu(robot.SignKey, time.Now().Unix())
-		if err != nil {
+	return robot.SendContext(context.Background(), request)
+}
+
+// SendContext 发送消息，支持取消
+// 配置签名密钥时，请求携带签名所用的时间戳；若因时钟偏差导致签名校验失败，按服务器时间校正后重试一次
+func (robot *GroupRobot) SendContext(ctx context.Context, request *GroupRobotRequest) (response GroupRobotResponse, err error) {
+	if robot.SignKey == "" {
+		return robot.SendRawContext(ctx, request)
+	}
+
+	if err = robot.Sign(request); err != nil {
+		return response, err
+	}
+
+	response, serverTime, err := robot.send(ctx, request)
+	if response.Code == CodeSignMismatch && robot.syncClock(serverTime) {
+		if err = robot.Sign(request); err != nil {
 			return response, err
 		}
 
-		request.Sign = signValue
+		response, _, err = robot.send(ctx, request)
+	}
+
+	return response, err
+}
+
+// Sign 签名，写入时间戳与签名
+func (robot *GroupRobot) Sign(request *GroupRobotRequest) error {
+	timestamp := time.Now().Unix() + robot.offset.Load()
+
+	signValue, err := sign.FeiShu(robot.SignKey, timestamp)
+	if err != nil {
+		return err
+	}
+
+	request.Timestamp = strconv.FormatInt(timestamp, 10)
+	request.Sign = signValue
+	return nil
+}
+
+// ClockOffset 本地时钟与飞书服务器的偏差
+func (robot *GroupRobot) ClockOffset() time.Duration {
+	return time.Duration(robot.offset.Load()) * time.Second
+}
+
+// syncClock 按服务器时间校正时钟偏差，偏差有变化时返回true
+func (robot *GroupRobot) syncClock(serverTime time.Time) bool {
+	if serverTime.IsZero() {
+		return false
 	}
 
-	return robot.SendRaw(request)
+	offset := serverTime.Unix() - time.Now().Unix()
+	// Date头精度为秒，加上网络耗时，小偏差无需校正
+	if diff := offset - robot.offset.Load(); diff > -5 && diff < 5 {
+		return false
+	}
+
+	robot.offset.Store(offset)
+	return true
 }
 
 // SendText 发送文本消息（快捷方法）
@@ -109,3 +265,30 @@ func (robot *GroupRobot) SendCard(card any) (GroupRobotResponse, error) {
 	request.BuildCardMessage(card)
 	return robot.Send(request)
 }
+
+// statusError 服务端错误
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("feishu: unexpected http status %d", e.code)
+}
+
+// isTransient 是否可重试，只包括请求未发出的连接失败与 429、5xx 响应
+// webhook 请求不幂等，请求发出后的读超时、连接断开等错误不重试，避免重复发送
+func isTransient(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return true
+	}
+
+	if errors.Is(err, fasthttp.ErrDialTimeout) ||
+		errors.Is(err, fasthttp.ErrNoFreeConns) ||
+		errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
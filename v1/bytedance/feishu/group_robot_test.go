@@ -1,11 +1,54 @@
 package feishu
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/lynnclub/go/v1/sign"
+	"github.com/valyala/fasthttp"
 )
 
+// newFakeFeishu 模拟飞书服务器，按飞书规则校验签名，skew为服务器相对本地的时钟偏差
+func newFakeFeishu(t *testing.T, signKey string, skew time.Duration, received *[]GroupRobotRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().Add(skew)
+		w.Header().Set("Date", now.UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/json")
+
+		var request GroupRobotRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("请求体解析失败: %v", err)
+			w.Write([]byte(`{"code":9499,"msg":"Bad Request","data":{}}`))
+			return
+		}
+		if received != nil {
+			*received = append(*received, request)
+		}
+
+		if signKey != "" {
+			timestamp, err := strconv.ParseInt(request.Timestamp, 10, 64)
+			expected, _ := sign.FeiShu(signKey, timestamp)
+			diff := now.Unix() - timestamp
+			if err != nil || diff > 3600 || diff < -3600 || request.Sign != expected {
+				w.Write([]byte(`{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time","data":{}}`))
+				return
+			}
+		}
+
+		w.Write([]byte(`{"code":0,"msg":"success","data":{}}`))
+	}))
+}
+
 // TestNewGroupRobot 测试创建群机器人客户端
 func TestNewGroupRobot(t *testing.T) {
 	webhook := "https://open.feishu.cn/open-apis/bot/v2/hook/test"
@@ -409,3 +452,347 @@ func TestSendCard(t *testing.T) {
 		t.Errorf("期望Code为0，实际为%d", response.Code)
 	}
 }
+
+// TestSendSignVerified 测试签名由模拟飞书服务器校验通过
+func TestSendSignVerified(t *testing.T) {
+	var received []GroupRobotRequest
+	server := newFakeFeishu(t, "test_sign_key", 0, &received)
+	defer server.Close()
+
+	robot := NewGroupRobot(server.URL, "test_sign_key")
+	response, err := robot.SendText("测试消息")
+	if err != nil {
+		t.Fatalf("Send不应该返回错误: %v", err)
+	}
+	if response.Code != 0 {
+		t.Errorf("期望Code为0，实际为%d", response.Code)
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("期望收到1个请求，实际为%d", len(received))
+	}
+	timestamp, err := strconv.ParseInt(received[0].Timestamp, 10, 64)
+	if err != nil {
+		t.Fatalf("时间戳格式错误: %s", received[0].Timestamp)
+	}
+	expected, _ := sign.FeiShu("test_sign_key", timestamp)
+	if received[0].Sign != expected {
+		t.Errorf("签名与时间戳不匹配，期望%s，实际为%s", expected, received[0].Sign)
+	}
+}
+
+// TestSendWithoutSignKeyNoTimestamp 测试不带签名时不发送时间戳
+func TestSendWithoutSignKeyNoTimestamp(t *testing.T) {
+	var received []GroupRobotRequest
+	server := newFakeFeishu(t, "", 0, &received)
+	defer server.Close()
+
+	robot := NewGroupRobot(server.URL, "")
+	if _, err := robot.SendText("测试消息"); err != nil {
+		t.Fatalf("Send不应该返回错误: %v", err)
+	}
+
+	if received[0].Timestamp != "" || received[0].Sign != "" {
+		t.Errorf("期望Timestamp和Sign为空，实际为%s、%s", received[0].Timestamp, received[0].Sign)
+	}
+}
+
+// TestSendWrongSignKey 测试签名密钥错误
+func TestSendWrongSignKey(t *testing.T) {
+	var received []GroupRobotRequest
+	server := newFakeFeishu(t, "right_key", 0, &received)
+	defer server.Close()
+
+	robot := NewGroupRobot(server.URL, "wrong_key")
+	response, err := robot.SendText("测试消息")
+	if err == nil {
+		t.Error("签名错误时应该返回错误")
+	}
+	if response.Code != CodeSignMismatch {
+		t.Errorf("期望Code为%d，实际为%d", CodeSignMismatch, response.Code)
+	}
+
+	// 时钟无偏差，不应该重试
+	if len(received) != 1 {
+		t.Errorf("期望收到1个请求，实际为%d", len(received))
+	}
+}
+
+// TestSendClockSkew 测试时钟偏差超过1小时，按服务器时间校正后重试
+func TestSendClockSkew(t *testing.T) {
+	var received []GroupRobotRequest
+	server := newFakeFeishu(t, "test_sign_key", 2*time.Hour, &received)
+	defer server.Close()
+
+	robot := NewGroupRobot(server.URL, "test_sign_key")
+	response, err := robot.SendText("测试消息")
+	if err != nil {
+		t.Fatalf("校正时钟后应该发送成功: %v", err)
+	}
+	if response.Code != 0 {
+		t.Errorf("期望Code为0，实际为%d", response.Code)
+	}
+	if len(received) != 2 {
+		t.Errorf("期望收到2个请求，实际为%d", len(received))
+	}
+
+	offset := robot.ClockOffset()
+	if offset < 2*time.Hour-5*time.Second || offset > 2*time.Hour+5*time.Second {
+		t.Errorf("期望时钟偏差约为2小时，实际为%v", offset)
+	}
+
+	// 校正后再次发送，一次成功
+	received = received[:0]
+	if _, err = robot.SendText("测试消息"); err != nil {
+		t.Errorf("Send不应该返回错误: %v", err)
+	}
+	if len(received) != 1 {
+		t.Errorf("期望收到1个请求，实际为%d", len(received))
+	}
+}
+
+// TestNewGroupRobotOptions 测试客户端选项
+func TestNewGroupRobotOptions(t *testing.T) {
+	client := &fasthttp.Client{}
+	tlsConfig := &tls.Config{ServerName: "open.feishu.cn"}
+	robot := NewGroupRobot("https://example.com", "",
+		WithClient(client),
+		WithTimeout(5*time.Second),
+		WithProxy("http://127.0.0.1:8080"),
+		WithTLSConfig(tlsConfig),
+		WithRetry(2, time.Second),
+	)
+
+	if robot.client != client {
+		t.Error("期望使用自定义客户端")
+	}
+	if robot.timeout != 5*time.Second {
+		t.Errorf("期望超时为5秒，实际为%v", robot.timeout)
+	}
+	if client.DialTimeout == nil {
+		t.Error("期望设置代理拨号")
+	}
+	if client.TLSConfig != tlsConfig {
+		t.Error("期望设置TLS配置")
+	}
+	if robot.retry != 2 || robot.retryInterval != time.Second {
+		t.Errorf("期望重试2次间隔1秒，实际为%d次%v", robot.retry, robot.retryInterval)
+	}
+
+	// WithClient 放在 WithProxy、WithTLSConfig 之后同样生效
+	client = &fasthttp.Client{}
+	robot = NewGroupRobot("https://example.com", "",
+		WithProxy("http://127.0.0.1:8080"),
+		WithTLSConfig(tlsConfig),
+		WithClient(client),
+	)
+	if robot.client != client || client.DialTimeout == nil || client.TLSConfig != tlsConfig {
+		t.Error("期望代理与TLS配置应用到后设置的自定义客户端")
+	}
+
+	// 未指定客户端时，代理与TLS使用独立客户端
+	robot = NewGroupRobot("https://example.com", "", WithTLSConfig(tlsConfig))
+	if robot.client == nil || robot.client.TLSConfig != tlsConfig {
+		t.Error("期望新建独立客户端")
+	}
+}
+
+// TestSendContextCanceled 测试取消发送
+func TestSendContextCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.Write([]byte(`{"code":0,"msg":"success","data":{}}`))
+	}))
+	defer server.Close()
+
+	robot := NewGroupRobot(server.URL, "")
+	request := &GroupRobotRequest{}
+	request.BuildTextMessage("测试消息")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := robot.SendContext(ctx, request)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("期望返回context.Canceled，实际为%v", err)
+	}
+	if time.Since(start) > 400*time.Millisecond {
+		t.Error("取消后应该立即返回")
+	}
+
+	// 已取消的ctx不发送请求
+	if _, err = robot.SendContext(ctx, request); !errors.Is(err, context.Canceled) {
+		t.Errorf("期望返回context.Canceled，实际为%v", err)
+	}
+}
+
+// TestSendTimeout 测试超时
+func TestSendTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.Write([]byte(`{"code":0,"msg":"success","data":{}}`))
+	}))
+	defer server.Close()
+
+	robot := NewGroupRobot(server.URL, "", WithTimeout(50*time.Millisecond))
+	if _, err := robot.SendText("测试消息"); !errors.Is(err, fasthttp.ErrTimeout) {
+		t.Errorf("期望返回超时错误，实际为%v", err)
+	}
+
+	// ctx截止时间早于超时时间
+	robot = NewGroupRobot(server.URL, "")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	request := &GroupRobotRequest{}
+	request.BuildTextMessage("测试消息")
+	if _, err := robot.SendContext(ctx, request); err == nil {
+		t.Error("期望返回超时错误")
+	}
+}
+
+// TestSendRetry 测试 5xx 重试
+func TestSendRetry(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"code":0,"msg":"success","data":{}}`))
+	}))
+	defer server.Close()
+
+	// 不重试
+	robot := NewGroupRobot(server.URL, "")
+	if _, err := robot.SendText("测试消息"); err == nil {
+		t.Error("期望返回错误")
+	}
+
+	calls.Store(0)
+	robot = NewGroupRobot(server.URL, "", WithRetry(2, 10*time.Millisecond))
+	response, err := robot.SendText("测试消息")
+	if err != nil {
+		t.Fatalf("重试后应该发送成功: %v", err)
+	}
+	if response.Code != 0 {
+		t.Errorf("期望Code为0，实际为%d", response.Code)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("期望请求3次，实际为%d", calls.Load())
+	}
+
+	// 业务错误不重试
+	calls.Store(0)
+	server2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"code":9499,"msg":"Bad Request","data":{}}`))
+	}))
+	defer server2.Close()
+
+	robot = NewGroupRobot(server2.URL, "", WithRetry(2, 10*time.Millisecond))
+	if _, err = robot.SendText("测试消息"); err == nil {
+		t.Error("期望返回错误")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("期望请求1次，实际为%d", calls.Load())
+	}
+}
+
+// TestSendRetryConnectionRefused 测试连接失败重试
+func TestSendRetryConnectionRefused(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	robot := NewGroupRobot(url, "", WithRetry(1, 10*time.Millisecond))
+	if _, err := robot.SendText("测试消息"); err == nil {
+		t.Error("期望返回连接错误")
+	} else if !isTransient(err) {
+		t.Errorf("连接失败应该可重试: %v", err)
+	}
+}
+
+// TestSendNotRetryAfterWritten 测试请求发出后的超时不重试，429 重试
+func TestSendNotRetryAfterWritten(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	robot := NewGroupRobot(server.URL, "", WithTimeout(50*time.Millisecond), WithRetry(2, 10*time.Millisecond))
+	if _, err := robot.SendText("测试消息"); err == nil {
+		t.Error("期望返回超时错误")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("请求发出后不应该重试，实际请求%d次", calls.Load())
+	}
+
+	calls.Store(0)
+	server2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"code":0,"msg":"success","data":{}}`))
+	}))
+	defer server2.Close()
+
+	robot = NewGroupRobot(server2.URL, "", WithRetry(1, 10*time.Millisecond))
+	if _, err := robot.SendText("测试消息"); err != nil || calls.Load() != 2 {
+		t.Errorf("429 应该重试 %v %d", err, calls.Load())
+	}
+}
+
+// TestWithProxy 测试代理协议与发送时的超时
+func TestWithProxy(t *testing.T) {
+	// 接受连接但不响应 CONNECT 的代理
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		var conns []net.Conn
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				for _, conn := range conns {
+					conn.Close()
+				}
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+
+	// WithTimeout 在 WithProxy 之后仍生效
+	robot := NewGroupRobot("http://open.feishu.cn/hook", "", WithProxy("http://"+listener.Addr().String()), WithTimeout(100*time.Millisecond))
+	start := time.Now()
+	if _, err := robot.SendText("测试消息"); err == nil {
+		t.Error("代理无响应时应该返回错误")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("代理连接应该按发送时的超时结束，耗时%v", time.Since(start))
+	}
+
+	// socks5 代理
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	address := closed.Addr().String()
+	closed.Close()
+	robot = NewGroupRobot("http://open.feishu.cn/hook", "", WithProxy("socks5://"+address))
+	if _, err := robot.SendText("测试消息"); err == nil || !isTransient(err) {
+		t.Errorf("socks5 代理连接失败应该可重试: %v", err)
+	}
+
+	robot = NewGroupRobot("http://open.feishu.cn/hook", "", WithProxy("ftp://"+address))
+	if _, err := robot.SendText("测试消息"); err == nil || !strings.Contains(err.Error(), "unknown scheme") {
+		t.Errorf("不支持的代理协议应该返回错误: %v", err)
+	}
+}
@@ -10,12 +10,18 @@ type GroupRobotResponse struct {
 	Data any    `json:"data"` // 响应数据
 }
 
+// 错误码
+const (
+	CodeSignMismatch = 19021 // 签名校验失败，或时间戳与服务器时间相差超过1小时
+)
+
 // GroupRobotRequest 飞书群机器人API请求结构
 type GroupRobotRequest struct {
-	MsgType string `json:"msg_type"`          // 消息类型
-	Sign    string `json:"sign,omitempty"`    // 签名（可选）
-	Content any    `json:"content,omitempty"` // 消息内容（普通消息使用）
-	Card    any    `json:"card,omitempty"`    // 卡片内容（交互式消息使用）
+	Timestamp string `json:"timestamp,omitempty"` // 签名时间戳，秒级（签名时必填）
+	Sign      string `json:"sign,omitempty"`      // 签名（可选）
+	MsgType   string `json:"msg_type"`            // 消息类型
+	Content   any    `json:"content,omitempty"`   // 消息内容（普通消息使用）
+	Card      any    `json:"card,omitempty"`      // 卡片内容（交互式消息使用）
 }
 
 // BuildTextMessage 构建文本消息
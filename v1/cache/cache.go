@@ -0,0 +1,279 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/lynnclub/go/v1/redis"
+	"golang.org/x/sync/singleflight"
+)
+
+var ErrNotFound = errors.New("cache: not found")
+
+const (
+	flagValue    byte = 'v' // 有值
+	flagNotFound byte = 'n' // 不存在，负缓存
+	headerSize        = 13  // 标记1字节 + 过期时间8字节 + 加载耗时4字节（微秒）
+)
+
+// Cache 旁路缓存，未命中时调用 loader 加载并写入 Redis
+type Cache struct {
+	Client      string        // redis配置名称，留空使用default
+	Prefix      string        // 键前缀
+	Serializer  Serializer    // 序列化，默认 JSON
+	NegativeTTL time.Duration // 不存在结果的缓存时间，默认1分钟，小于0时不缓存
+	Jitter      float64       // 过期时间随机缩短的最大比例，默认0.1，避免同时过期，小于0时关闭
+	Beta        float64       // 提前刷新系数，默认1，越大越早刷新，小于0时关闭
+
+	group singleflight.Group
+	stats cacheStats
+}
+
+// Instance 缓存实例，*Cache 或 *TwoLevel
+type Instance interface {
+	get(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (any, error)) (byte, []byte, error)
+	serializer() Serializer
+}
+
+// Stats 命中统计
+type Stats struct {
+	LocalHits   uint64 `json:"local_hits"`   // 本地命中
+	LocalMisses uint64 `json:"local_misses"` // 本地未命中
+	RedisHits   uint64 `json:"redis_hits"`   // Redis命中
+	RedisMisses uint64 `json:"redis_misses"` // Redis未命中，调用 loader
+	LoadErrors  uint64 `json:"load_errors"`  // loader 失败
+}
+
+type cacheStats struct {
+	redisHits   atomic.Uint64
+	redisMisses atomic.Uint64
+	loadErrors  atomic.Uint64
+}
+
+// Default 默认实例，使用 default redis 配置
+var Default = &Cache{}
+
+// New 实例化
+func New(client, prefix string) *Cache {
+	return &Cache{Client: client, Prefix: prefix}
+}
+
+// Get 使用默认实例读取缓存，未命中时调用 loader 加载
+// loader 返回 ErrNotFound 时缓存不存在的结果，避免穿透
+func Get[T any](ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	return GetWith(ctx, Default, key, ttl, loader)
+}
+
+// GetWith 使用指定实例读取缓存，未命中时调用 loader 加载
+// 并发未命中时只有一个 loader 执行；临近过期时按概率提前在后台刷新
+func GetWith[T any](ctx context.Context, c Instance, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+
+	load := func(ctx context.Context) (any, error) {
+		return loader(ctx)
+	}
+
+	flag, payload, err := c.get(ctx, key, ttl, load)
+	if err != nil {
+		return result, err
+	}
+	if flag == flagNotFound {
+		return result, ErrNotFound
+	}
+
+	err = c.serializer().Unmarshal(payload, &result)
+	return result, err
+}
+
+// Set 写入缓存
+func (c *Cache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	payload, err := c.serializer().Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return c.write(ctx, key, flagValue, payload, ttl, 0)
+}
+
+// Stats 命中统计
+func (c *Cache) Stats() Stats {
+	return Stats{
+		RedisHits:   c.stats.redisHits.Load(),
+		RedisMisses: c.stats.redisMisses.Load(),
+		LoadErrors:  c.stats.loadErrors.Load(),
+	}
+}
+
+// Delete 删除缓存，数据变更后调用
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	client, err := redis.Get(ctx, c.Client)
+	if err != nil {
+		return err
+	}
+
+	// 逐个删除，cluster模式下多个键可能不在同一槽位
+	pipe := client.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, c.Prefix+key)
+	}
+	_, err = pipe.Exec(ctx)
+
+	return err
+}
+
+// get 读取缓存，未命中或需提前刷新时加载
+func (c *Cache) get(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (any, error)) (byte, []byte, error) {
+	// 连接失败与读取失败均视为未命中
+	client, err := redis.Get(ctx, c.Client)
+	var data []byte
+	if err == nil {
+		data, err = client.Get(ctx, c.Prefix+key).Bytes()
+	}
+	if err == nil {
+		if flag, expire, delta, payload, ok := decodeEntry(data); ok {
+			c.stats.redisHits.Add(1)
+			if c.shouldRefresh(expire, delta) {
+				go c.group.Do(key, func() (any, error) {
+					return c.load(context.WithoutCancel(ctx), key, ttl, loader)
+				})
+			}
+			return flag, payload, nil
+		}
+	} else if ctx.Err() != nil {
+		return 0, nil, ctx.Err()
+	}
+
+	// 未命中、数据损坏或 Redis 故障时加载，Redis 故障不影响业务
+	c.stats.redisMisses.Add(1)
+	channel := c.group.DoChan(key, func() (any, error) {
+		return c.load(context.WithoutCancel(ctx), key, ttl, loader)
+	})
+
+	select {
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	case result := <-channel:
+		if result.Err != nil {
+			return 0, nil, result.Err
+		}
+		entry := result.Val.(loaded)
+		return entry.flag, entry.payload, nil
+	}
+}
+
+type loaded struct {
+	flag    byte
+	payload []byte
+}
+
+// load 调用 loader 并写入缓存，写入失败不影响返回
+func (c *Cache) load(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (any, error)) (loaded, error) {
+	start := time.Now()
+	value, err := loader(ctx)
+	delta := time.Since(start)
+
+	if errors.Is(err, ErrNotFound) {
+		if negativeTTL := c.negativeTTL(); negativeTTL > 0 {
+			_ = c.write(ctx, key, flagNotFound, nil, negativeTTL, delta)
+		}
+		return loaded{flag: flagNotFound}, nil
+	} else if err != nil {
+		c.stats.loadErrors.Add(1)
+		return loaded{}, err
+	}
+
+	payload, err := c.serializer().Marshal(value)
+	if err != nil {
+		return loaded{}, err
+	}
+
+	_ = c.write(ctx, key, flagValue, payload, ttl, delta)
+	return loaded{flag: flagValue, payload: payload}, nil
+}
+
+// write 写入缓存，过期时间加随机抖动
+func (c *Cache) write(ctx context.Context, key string, flag byte, payload []byte, ttl, delta time.Duration) error {
+	ttl = c.jitter(ttl)
+	data := encodeEntry(flag, time.Now().Add(ttl), delta, payload)
+
+	client, err := redis.Get(ctx, c.Client)
+	if err != nil {
+		return err
+	}
+
+	return client.Set(ctx, c.Prefix+key, data, ttl).Err()
+}
+
+// shouldRefresh 概率提前刷新（XFetch），越临近过期、加载越慢，刷新概率越高
+func (c *Cache) shouldRefresh(expire time.Time, delta time.Duration) bool {
+	beta := c.Beta
+	if beta < 0 || delta <= 0 {
+		return false
+	}
+	if beta == 0 {
+		beta = 1
+	}
+
+	gap := time.Duration(-float64(delta) * beta * math.Log(1-rand.Float64()))
+	return !time.Now().Add(gap).Before(expire)
+}
+
+func (c *Cache) jitter(ttl time.Duration) time.Duration {
+	jitter := c.Jitter
+	if jitter < 0 || ttl <= 0 {
+		return ttl
+	}
+	if jitter == 0 {
+		jitter = 0.1
+	}
+
+	return ttl - time.Duration(float64(ttl)*min(jitter, 1)*rand.Float64())
+}
+
+func (c *Cache) negativeTTL() time.Duration {
+	if c.NegativeTTL == 0 {
+		return time.Minute
+	}
+
+	return c.NegativeTTL
+}
+
+func (c *Cache) serializer() Serializer {
+	if c.Serializer == nil {
+		return JSON{}
+	}
+
+	return c.Serializer
+}
+
+// encodeEntry 编码缓存条目，头部记录过期时间与加载耗时，用于提前刷新
+func encodeEntry(flag byte, expire time.Time, delta time.Duration, payload []byte) []byte {
+	data := make([]byte, headerSize+len(payload))
+	data[0] = flag
+	binary.BigEndian.PutUint64(data[1:9], uint64(expire.UnixMilli()))
+	binary.BigEndian.PutUint32(data[9:13], uint32(min(delta.Microseconds(), math.MaxUint32)))
+	copy(data[headerSize:], payload)
+
+	return data
+}
+
+// decodeEntry 解码缓存条目
+func decodeEntry(data []byte) (flag byte, expire time.Time, delta time.Duration, payload []byte, ok bool) {
+	if len(data) < headerSize || (data[0] != flagValue && data[0] != flagNotFound) {
+		return 0, time.Time{}, 0, nil, false
+	}
+
+	expire = time.UnixMilli(int64(binary.BigEndian.Uint64(data[1:9])))
+	delta = time.Duration(binary.BigEndian.Uint32(data[9:13])) * time.Microsecond
+
+	return data[0], expire, delta, data[headerSize:], true
+}
@@ -0,0 +1,266 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/lynnclub/go/v1/redis"
+)
+
+type user struct {
+	Id   int    `json:"id" msgpack:"id"`
+	Name string `json:"name" msgpack:"name"`
+}
+
+func setupCache(t *testing.T, name string) (*miniredis.Miniredis, *Cache) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	redis.Add(name, redis.Option{Address: []string{s.Addr()}})
+
+	return s, New(name, "test:")
+}
+
+func TestGet(t *testing.T) {
+	s, c := setupCache(t, "cache_get")
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	loader := func(ctx context.Context) (user, error) {
+		calls.Add(1)
+		return user{Id: 1, Name: "lynn"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		value, err := GetWith(ctx, c, "user:1", time.Minute, loader)
+		if err != nil || value.Name != "lynn" {
+			t.Fatalf("Unexpected %+v %v", value, err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected loader called once, got %d", calls.Load())
+	}
+
+	// 过期时间随机缩短，不超过10%
+	if ttl := s.TTL("test:user:1"); ttl > time.Minute || ttl < 54*time.Second {
+		t.Errorf("Expected ttl with jitter, got %v", ttl)
+	}
+
+	// 删除后重新加载
+	if err := c.Delete(ctx, "user:1"); err != nil {
+		t.Fatal(err)
+	}
+	GetWith(ctx, c, "user:1", time.Minute, loader)
+	if calls.Load() != 2 {
+		t.Errorf("Expected reload after delete, got %d", calls.Load())
+	}
+}
+
+func TestGetSingleflight(t *testing.T) {
+	_, c := setupCache(t, "cache_singleflight")
+
+	var calls atomic.Int32
+	loader := func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return 42, nil
+	}
+
+	var wait sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			if value, err := GetWith(context.Background(), c, "answer", time.Minute, loader); err != nil || value != 42 {
+				t.Errorf("Unexpected %d %v", value, err)
+			}
+		}()
+	}
+	wait.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected concurrent misses collapsed, got %d loads", calls.Load())
+	}
+}
+
+func TestGetNotFound(t *testing.T) {
+	s, c := setupCache(t, "cache_not_found")
+	c.NegativeTTL = 10 * time.Second
+	c.Jitter = -1
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	loader := func(ctx context.Context) (*user, error) {
+		calls.Add(1)
+		return nil, ErrNotFound
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := GetWith(ctx, c, "user:404", time.Minute, loader); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Expected ErrNotFound, got %v", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected negative cache hit, got %d loads", calls.Load())
+	}
+	if ttl := s.TTL("test:user:404"); ttl != 10*time.Second {
+		t.Errorf("Expected negative ttl 10s, got %v", ttl)
+	}
+
+	// 关闭负缓存
+	c.NegativeTTL = -1
+	GetWith(ctx, c, "user:405", time.Minute, loader)
+	if s.Exists("test:user:405") {
+		t.Error("Expected no negative cache")
+	}
+}
+
+func TestGetLoaderError(t *testing.T) {
+	s, c := setupCache(t, "cache_error")
+
+	_, err := GetWith(context.Background(), c, "broken", time.Minute, func(ctx context.Context) (int, error) {
+		return 0, errors.New("db down")
+	})
+	if err == nil || err.Error() != "db down" {
+		t.Errorf("Expected loader error, got %v", err)
+	}
+	if s.Exists("test:broken") {
+		t.Error("Loader error should not be cached")
+	}
+}
+
+func TestGetEarlyRefresh(t *testing.T) {
+	s, c := setupCache(t, "cache_refresh")
+	ctx := context.Background()
+
+	// 即将过期且加载耗时较长，必然提前刷新
+	s.Set("test:hot", string(encodeEntry(flagValue, time.Now().Add(time.Millisecond), time.Second, []byte("1"))))
+
+	var calls atomic.Int32
+	loader := func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		return 2, nil
+	}
+
+	// 先返回旧值，后台刷新
+	if value, _ := GetWith(ctx, c, "hot", time.Minute, loader); value != 1 {
+		t.Errorf("Expected stale value 1, got %d", value)
+	}
+	for i := 0; i < 100 && calls.Load() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if value, _ := GetWith(ctx, c, "hot", time.Minute, loader); value != 2 {
+		t.Errorf("Expected refreshed value 2, got %d", value)
+	}
+
+	// 关闭提前刷新
+	c.Beta = -1
+	s.Set("test:cold", string(encodeEntry(flagValue, time.Now().Add(time.Millisecond), time.Second, []byte("1"))))
+	calls.Store(0)
+	GetWith(ctx, c, "cold", time.Minute, loader)
+	time.Sleep(50 * time.Millisecond)
+	if calls.Load() != 0 {
+		t.Error("Expected no early refresh when disabled")
+	}
+}
+
+func TestGetRedisDown(t *testing.T) {
+	s, c := setupCache(t, "cache_down")
+	redis.Universal("cache_down")
+	s.Close()
+
+	value, err := GetWith(context.Background(), c, "user:1", time.Minute, func(ctx context.Context) (string, error) {
+		return "from db", nil
+	})
+	if err != nil || value != "from db" {
+		t.Errorf("Expected fallback to loader, got %s %v", value, err)
+	}
+}
+
+func TestGetRedisDownColdPool(t *testing.T) {
+	// 不预先建立连接，连接失败不能 panic
+	s, c := setupCache(t, "cache_down_cold")
+	s.Close()
+
+	ctx := context.Background()
+	value, err := GetWith(ctx, c, "user:1", time.Minute, func(ctx context.Context) (string, error) {
+		return "from db", nil
+	})
+	if err != nil || value != "from db" {
+		t.Errorf("Expected fallback to loader, got %s %v", value, err)
+	}
+	if err := c.Delete(ctx, "user:1"); err == nil {
+		t.Error("Expected delete error when redis down")
+	}
+
+	twoLevel := NewTwoLevel("cache_down_cold", "test:", 10)
+	value, err = GetWith(ctx, twoLevel, "user:2", time.Minute, func(ctx context.Context) (string, error) {
+		return "from db", nil
+	})
+	if err != nil || value != "from db" {
+		t.Errorf("Expected fallback to loader, got %s %v", value, err)
+	}
+	if err := twoLevel.Subscribe(ctx); err == nil {
+		t.Error("Expected subscribe error when redis down")
+	}
+}
+
+func TestGetContextCanceled(t *testing.T) {
+	_, c := setupCache(t, "cache_cancel")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := GetWith(ctx, c, "slow", time.Minute, func(ctx context.Context) (int, error) {
+		time.Sleep(200 * time.Millisecond)
+		return 1, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestGetDefault(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	redis.Add("default", redis.Option{Address: []string{s.Addr()}})
+
+	value, err := Get(context.Background(), "default:key", time.Minute, func(ctx context.Context) ([]int, error) {
+		return []int{1, 2}, nil
+	})
+	if err != nil || len(value) != 2 {
+		t.Errorf("Unexpected %v %v", value, err)
+	}
+	if !s.Exists("default:key") {
+		t.Error("Expected default instance without prefix")
+	}
+}
+
+func TestSetMsgpack(t *testing.T) {
+	_, c := setupCache(t, "cache_msgpack")
+	c.Serializer = Msgpack{}
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "user:2", user{Id: 2, Name: "msgpack"}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := GetWith(ctx, c, "user:2", time.Minute, func(ctx context.Context) (user, error) {
+		t.Error("Loader should not be called")
+		return user{}, nil
+	})
+	if err != nil || value.Name != "msgpack" || value.Id != 2 {
+		t.Errorf("Unexpected %+v %v", value, err)
+	}
+}
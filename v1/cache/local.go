@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Local 进程内 LRU 缓存，条目各自过期，并发安全
+type Local struct {
+	capacity int
+	items    map[string]*list.Element
+	list     *list.List
+	mutex    sync.Mutex
+}
+
+type localItem struct {
+	key    string
+	value  any
+	expire time.Time
+}
+
+// NewLocal 进程内缓存实例化，capacity 最大条目数，超出时淘汰最久未使用的
+func NewLocal(capacity int) *Local {
+	if capacity <= 0 {
+		panic("Local cache capacity must be positive")
+	}
+
+	return &Local{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		list:     list.New(),
+	}
+}
+
+// Get 读取，已过期视为不存在
+func (l *Local) Get(key string) (any, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	element, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := element.Value.(*localItem)
+	if time.Now().After(item.expire) {
+		l.remove(element)
+		return nil, false
+	}
+
+	l.list.MoveToFront(element)
+	return item.value, true
+}
+
+// Set 写入，ttl 小于等于0时不写入
+func (l *Local) Set(key string, value any, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	expire := time.Now().Add(ttl)
+	if element, ok := l.items[key]; ok {
+		item := element.Value.(*localItem)
+		item.value, item.expire = value, expire
+		l.list.MoveToFront(element)
+		return
+	}
+
+	l.items[key] = l.list.PushFront(&localItem{key: key, value: value, expire: expire})
+	for l.list.Len() > l.capacity {
+		l.remove(l.list.Back())
+	}
+}
+
+// Delete 删除
+func (l *Local) Delete(keys ...string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, key := range keys {
+		if element, ok := l.items[key]; ok {
+			l.remove(element)
+		}
+	}
+}
+
+// Clear 清空
+func (l *Local) Clear() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.items = make(map[string]*list.Element)
+	l.list.Init()
+}
+
+// Len 条目数，包含未清理的过期条目
+func (l *Local) Len() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.list.Len()
+}
+
+func (l *Local) remove(element *list.Element) {
+	l.list.Remove(element)
+	delete(l.items, element.Value.(*localItem).key)
+}
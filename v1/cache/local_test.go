@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocal(t *testing.T) {
+	local := NewLocal(2)
+
+	local.Set("a", 1, time.Minute)
+	local.Set("b", 2, time.Minute)
+	if value, ok := local.Get("a"); !ok || value != 1 {
+		t.Errorf("Expected a=1, got %v %v", value, ok)
+	}
+
+	// 超出容量淘汰最久未使用的 b
+	local.Set("c", 3, time.Minute)
+	if _, ok := local.Get("b"); ok {
+		t.Error("Expected b evicted")
+	}
+	if _, ok := local.Get("a"); !ok {
+		t.Error("Expected a kept")
+	}
+	if local.Len() != 2 {
+		t.Errorf("Expected len 2, got %d", local.Len())
+	}
+
+	// 覆盖
+	local.Set("a", 10, time.Minute)
+	if value, _ := local.Get("a"); value != 10 {
+		t.Errorf("Expected a=10, got %v", value)
+	}
+
+	local.Delete("a", "none")
+	if _, ok := local.Get("a"); ok {
+		t.Error("Expected a deleted")
+	}
+
+	local.Clear()
+	if local.Len() != 0 {
+		t.Errorf("Expected empty, got %d", local.Len())
+	}
+}
+
+func TestLocalExpire(t *testing.T) {
+	local := NewLocal(10)
+
+	local.Set("short", 1, 20*time.Millisecond)
+	local.Set("zero", 1, 0)
+	if _, ok := local.Get("zero"); ok {
+		t.Error("Expected zero ttl not stored")
+	}
+	if _, ok := local.Get("short"); !ok {
+		t.Error("Expected short stored")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := local.Get("short"); ok {
+		t.Error("Expected short expired")
+	}
+	if local.Len() != 0 {
+		t.Errorf("Expected expired item removed, got %d", local.Len())
+	}
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"errors"
+
+	"github.com/lynnclub/go/v1/encoding/json"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Serializer 序列化
+type Serializer interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSON 使用 v1/encoding/json，可读性好，便于排查
+type JSON struct{}
+
+func (JSON) Marshal(v any) ([]byte, error) {
+	bytes := json.EncodeToByte(v)
+	if bytes == nil {
+		return nil, errors.New("cache: json encode failed")
+	}
+
+	return bytes, nil
+}
+
+func (JSON) Unmarshal(data []byte, v any) error {
+	return json.DecodeFromByte(data, v)
+}
+
+// Msgpack 体积更小，编解码更快
+type Msgpack struct{}
+
+func (Msgpack) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (Msgpack) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
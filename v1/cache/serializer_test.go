@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSerializer(t *testing.T) {
+	for _, serializer := range []Serializer{JSON{}, Msgpack{}} {
+		data, err := serializer.Marshal(user{Id: 1, Name: "lynn"})
+		if err != nil {
+			t.Fatalf("%T marshal failed: %v", serializer, err)
+		}
+
+		var decoded user
+		if err := serializer.Unmarshal(data, &decoded); err != nil || decoded.Name != "lynn" || decoded.Id != 1 {
+			t.Errorf("%T unexpected %+v %v", serializer, decoded, err)
+		}
+	}
+
+	if _, err := (JSON{}).Marshal(make(chan int)); err == nil {
+		t.Error("Expected json encode error")
+	}
+}
+
+func TestEntry(t *testing.T) {
+	expire := time.UnixMilli(time.Now().Add(time.Minute).UnixMilli())
+	data := encodeEntry(flagValue, expire, 1500*time.Microsecond, []byte("payload"))
+
+	flag, decodedExpire, delta, payload, ok := decodeEntry(data)
+	if !ok || flag != flagValue || !decodedExpire.Equal(expire) || delta != 1500*time.Microsecond || string(payload) != "payload" {
+		t.Errorf("Unexpected %c %v %v %s %v", flag, decodedExpire, delta, payload, ok)
+	}
+
+	if _, _, _, _, ok := decodeEntry([]byte("raw")); ok {
+		t.Error("Expected invalid entry")
+	}
+}
@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync/atomic"
+	"time"
+
+	"github.com/lynnclub/go/v1/encoding/json"
+	"github.com/lynnclub/go/v1/logger"
+	"github.com/lynnclub/go/v1/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const KeyInvalidate = redis.KeyBase + "cache:invalidate" //本地缓存失效通知频道
+
+// TwoLevel 二级缓存，进程内 LRU 在前，Redis 在后
+// 写入、删除时通过 Redis 发布订阅通知所有实例删除本地缓存，需先启动 Subscribe
+// 读取与通知并发时，本地可能短暂保留旧值，最长为 LocalTTL
+type TwoLevel struct {
+	*Cache
+	Local    *Local        // 本地缓存
+	LocalTTL time.Duration // 本地缓存时间，默认1分钟，不超过 Redis 缓存时间
+	Channel  string        // 通知频道，默认 KeyInvalidate，加 redis 默认键名构造器的前缀
+
+	id          string // 实例标识，忽略自己发出的通知
+	localHits   atomic.Uint64
+	localMisses atomic.Uint64
+}
+
+type invalidation struct {
+	Source string   `json:"source"`
+	Keys   []string `json:"keys"`
+}
+
+// NewTwoLevel 二级缓存实例化，capacity 本地最大条目数
+func NewTwoLevel(client, prefix string, capacity int) *TwoLevel {
+	bytes := make([]byte, 8)
+	_, _ = rand.Read(bytes)
+
+	return &TwoLevel{
+		Cache: New(client, prefix),
+		Local: NewLocal(capacity),
+		id:    hex.EncodeToString(bytes),
+	}
+}
+
+// Set 写入缓存，通知其他实例删除本地缓存
+func (c *TwoLevel) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	payload, err := c.serializer().Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err = c.write(ctx, key, flagValue, payload, ttl, 0); err != nil {
+		return err
+	}
+
+	c.Local.Set(c.Prefix+key, loaded{flag: flagValue, payload: payload}, min(c.localTTL(), ttl))
+	return c.publish(ctx, key)
+}
+
+// Delete 删除缓存，通知其他实例删除本地缓存
+func (c *TwoLevel) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	for _, key := range keys {
+		c.Local.Delete(c.Prefix + key)
+	}
+	if err := c.Cache.Delete(ctx, keys...); err != nil {
+		return err
+	}
+
+	return c.publish(ctx, keys...)
+}
+
+// Subscribe 订阅失效通知，阻塞直至ctx结束
+// 订阅成功及断线重连后清空本地缓存，避免错过通知导致长期不一致
+func (c *TwoLevel) Subscribe(ctx context.Context) error {
+	client, err := redis.Get(ctx, c.Client)
+	if err != nil {
+		return err
+	}
+
+	pubsub := client.Subscribe(ctx, c.channel())
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+	c.Local.Clear()
+
+	messages := pubsub.ChannelWithSubscriptions()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+
+			switch message := message.(type) {
+			case *goredis.Subscription:
+				c.Local.Clear()
+			case *goredis.Message:
+				var notice invalidation
+				if err := json.Decode(message.Payload, &notice); err != nil {
+					logger.Warn("缓存失效通知解析失败", message.Payload)
+					continue
+				}
+				if notice.Source != c.id {
+					c.Local.Delete(notice.Keys...)
+				}
+			}
+		}
+	}
+}
+
+// Stats 各级命中统计
+func (c *TwoLevel) Stats() Stats {
+	stats := c.Cache.Stats()
+	stats.LocalHits = c.localHits.Load()
+	stats.LocalMisses = c.localMisses.Load()
+
+	return stats
+}
+
+func (c *TwoLevel) get(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (any, error)) (byte, []byte, error) {
+	localKey := c.Prefix + key
+	if value, ok := c.Local.Get(localKey); ok {
+		c.localHits.Add(1)
+		entry := value.(loaded)
+		return entry.flag, entry.payload, nil
+	}
+	c.localMisses.Add(1)
+
+	flag, payload, err := c.Cache.get(ctx, key, ttl, loader)
+	if err != nil {
+		return flag, payload, err
+	}
+
+	localTTL := min(c.localTTL(), ttl)
+	if flag == flagNotFound {
+		localTTL = min(localTTL, c.negativeTTL())
+	}
+	c.Local.Set(localKey, loaded{flag: flag, payload: payload}, localTTL)
+
+	return flag, payload, nil
+}
+
+// publish 发布失效通知
+func (c *TwoLevel) publish(ctx context.Context, keys ...string) error {
+	fullKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		fullKeys = append(fullKeys, c.Prefix+key)
+	}
+
+	message := json.Encode(invalidation{Source: c.id, Keys: fullKeys})
+	client, err := redis.Get(ctx, c.Client)
+	if err != nil {
+		return err
+	}
+
+	return client.Publish(ctx, c.channel(), message).Err()
+}
+
+func (c *TwoLevel) localTTL() time.Duration {
+	if c.LocalTTL <= 0 {
+		return time.Minute
+	}
+
+	return c.LocalTTL
+}
+
+func (c *TwoLevel) channel() string {
+	if c.Channel == "" {
+		return redis.NamespaceKey(KeyInvalidate)
+	}
+
+	return c.Channel
+}
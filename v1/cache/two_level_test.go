@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/lynnclub/go/v1/redis"
+)
+
+// setupTwoLevel 模拟两个实例共用同一个 Redis
+func setupTwoLevel(t *testing.T, name string) (*miniredis.Miniredis, *TwoLevel, *TwoLevel) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	redis.Add(name, redis.Option{Address: []string{s.Addr()}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	instances := []*TwoLevel{NewTwoLevel(name, "test:", 100), NewTwoLevel(name, "test:", 100)}
+	for _, instance := range instances {
+		go instance.Subscribe(ctx)
+	}
+	// 等待订阅成功
+	for i := 0; i < 100 && s.PubSubNumSub(KeyInvalidate)[KeyInvalidate] < 2; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	return s, instances[0], instances[1]
+}
+
+// waitFor 等待条件满足
+func waitFor(condition func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
+
+func TestTwoLevelGet(t *testing.T) {
+	s, a, _ := setupTwoLevel(t, "two_level_get")
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	loader := func(ctx context.Context) (user, error) {
+		calls.Add(1)
+		return user{Id: 1, Name: "lynn"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if value, err := GetWith(ctx, a, "user:1", time.Minute, loader); err != nil || value.Name != "lynn" {
+			t.Fatalf("Unexpected %+v %v", value, err)
+		}
+	}
+
+	stats := a.Stats()
+	if calls.Load() != 1 || stats.LocalHits != 2 || stats.LocalMisses != 1 || stats.RedisMisses != 1 || stats.RedisHits != 0 {
+		t.Errorf("Unexpected stats %+v, loads %d", stats, calls.Load())
+	}
+
+	// 本地过期后从 Redis 读取
+	a.Local.Clear()
+	GetWith(ctx, a, "user:1", time.Minute, loader)
+	if stats = a.Stats(); stats.RedisHits != 1 || calls.Load() != 1 {
+		t.Errorf("Expected redis hit, got %+v", stats)
+	}
+
+	// 本地缓存时间不超过 Redis 缓存时间
+	a.Local.Clear()
+	s.Del("test:short")
+	GetWith(ctx, a, "short", 20*time.Millisecond, loader)
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := a.Local.Get("test:short"); ok {
+		t.Error("Expected local ttl capped by redis ttl")
+	}
+}
+
+func TestTwoLevelInvalidate(t *testing.T) {
+	_, a, b := setupTwoLevel(t, "two_level_invalidate")
+	ctx := context.Background()
+
+	loader := func(ctx context.Context) (string, error) {
+		return "v1", nil
+	}
+
+	// 两个实例都缓存到本地
+	GetWith(ctx, a, "config", time.Minute, loader)
+	GetWith(ctx, b, "config", time.Minute, loader)
+	if _, ok := b.Local.Get("test:config"); !ok {
+		t.Fatal("Expected b local cached")
+	}
+
+	// a 写入，b 收到通知删除本地缓存
+	if err := a.Set(ctx, "config", "v2", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if !waitFor(func() bool { _, ok := b.Local.Get("test:config"); return !ok }) {
+		t.Fatal("Expected b local invalidated")
+	}
+	if value, _ := GetWith(ctx, b, "config", time.Minute, loader); value != "v2" {
+		t.Errorf("Expected v2, got %s", value)
+	}
+	// 自己的通知不删除刚写入的本地缓存
+	if _, ok := a.Local.Get("test:config"); !ok {
+		t.Error("Expected a keeps own local value")
+	}
+
+	// b 删除，a 收到通知
+	if err := b.Delete(ctx, "config"); err != nil {
+		t.Fatal(err)
+	}
+	if !waitFor(func() bool { _, ok := a.Local.Get("test:config"); return !ok }) {
+		t.Error("Expected a local invalidated")
+	}
+}
+
+func TestTwoLevelNotFound(t *testing.T) {
+	_, a, _ := setupTwoLevel(t, "two_level_not_found")
+	a.NegativeTTL = 10 * time.Millisecond
+	ctx := context.Background()
+
+	loader := func(ctx context.Context) (int, error) {
+		return 0, ErrNotFound
+	}
+
+	if _, err := GetWith(ctx, a, "none", time.Minute, loader); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := GetWith(ctx, a, "none", time.Minute, loader); !errors.Is(err, ErrNotFound) || a.Stats().LocalHits != 1 {
+		t.Errorf("Expected local negative hit, got %v %+v", err, a.Stats())
+	}
+
+	// 本地负缓存时间不超过 NegativeTTL
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := a.Local.Get("test:none"); ok {
+		t.Error("Expected local negative cache expired")
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+var (
+	watchOnce      sync.Once
+	listeners      []func()
+	listenersMutex sync.Mutex
+)
+
+// OnChange 添加配置变更回调，配置文件变更并重新读取后依次调用，需调用 Watch 开启监听
+func OnChange(listener func()) {
+	listenersMutex.Lock()
+	defer listenersMutex.Unlock()
+
+	listeners = append(listeners, listener)
+}
+
+// Watch 监听配置文件变更，变更后重新读取并依次调用回调，需先调用 Start，重复调用只生效一次
+// 配置在监听协程中重新读取，读取时与业务中的 Viper 读取不互斥，建议只在回调中读取变更的配置
+func Watch() {
+	watchOnce.Do(func() {
+		Viper.OnConfigChange(func(event fsnotify.Event) {
+			fmt.Println("config changed:", event.Name)
+
+			listenersMutex.Lock()
+			current := append([]func(){}, listeners...)
+			listenersMutex.Unlock()
+
+			for _, listener := range current {
+				call(listener)
+			}
+		})
+		Viper.WatchConfig()
+	})
+}
+
+// call 调用回调，panic 不影响后续回调与监听
+func call(listener func()) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			fmt.Println("config change listener panic:", recovered)
+		}
+	}()
+
+	listener()
+}
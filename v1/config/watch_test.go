@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// TestWatch 测试配置文件变更后重新读取并调用回调
+func TestWatch(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "watch.yaml")
+	if err := os.WriteFile(filename, []byte("name: \"before\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	original := Viper
+	Viper = viper.New()
+	Viper.SetConfigFile(filename)
+	if err := Viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Viper = original })
+
+	changed := make(chan string, 10)
+	OnChange(func() {
+		panic("不影响后续回调")
+	})
+	OnChange(func() {
+		changed <- Viper.GetString("name")
+	})
+	Watch()
+	Watch()
+
+	if err := os.WriteFile(filename, []byte("name: \"after\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case name := <-changed:
+		if name != "after" {
+			t.Errorf("期望重新读取为after，实际%s", name)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("回调未调用")
+	}
+}
@@ -1,12 +1,16 @@
 package db
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/lynnclub/go/v1/lifecycle"
 	"gorm.io/driver/clickhouse"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -17,9 +21,11 @@ import (
 )
 
 var (
-	pool    = &sync.Map{}             //实例池
-	mutex   sync.Mutex                //互斥锁
-	options = make(map[string]Option) //配置池
+	pool         = &sync.Map{}             //实例池
+	mutex        sync.Mutex                //互斥锁
+	options      = make(map[string]Option) //配置池
+	optionsMutex sync.RWMutex              //配置池读写锁，Reload 时修改
+	register     sync.Once                 //注册到生命周期管理
 )
 
 type Option struct {
@@ -33,8 +39,18 @@ type Option struct {
 }
 
 func Add(name string, option Option) {
+	option, err := normalize(name, option)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	store(name, option)
+}
+
+// normalize 校验配置并填充默认值
+func normalize(name string, option Option) (Option, error) {
 	if option.DSN == "" {
-		panic("Option dsn empty " + name)
+		return option, errors.New("Option dsn empty " + name)
 	}
 
 	// 默认值
@@ -57,10 +73,35 @@ func Add(name string, option Option) {
 		option.SlowThreshold = 1
 	}
 
+	return option, nil
+}
+
+// store 保存配置，首次保存时注册到生命周期管理
+func store(name string, option Option) {
+	optionsMutex.Lock()
 	options[name] = option
+	optionsMutex.Unlock()
+
+	register.Do(func() {
+		lifecycle.Register(lifecycle.StagePool, "db", CloseAll)
+	})
+}
+
+// getOption 读取配置，可与 Reload 并发
+func getOption(name string) (Option, bool) {
+	optionsMutex.RLock()
+	defer optionsMutex.RUnlock()
+
+	option, ok := options[name]
+	return option, ok
 }
 
 func AddMap(name string, setting map[string]interface{}) {
+	Add(name, parseMap(setting))
+}
+
+// parseMap 解析 map 配置
+func parseMap(setting map[string]interface{}) Option {
 	option := Option{
 		DSN: setting["dsn"].(string),
 	}
@@ -84,7 +125,7 @@ func AddMap(name string, setting map[string]interface{}) {
 		option.SlowThreshold = slowThreshold.(int)
 	}
 
-	Add(name, option)
+	return option
 }
 
 func AddMapBatch(batch map[string]interface{}) {
@@ -93,27 +134,62 @@ func AddMapBatch(batch map[string]interface{}) {
 	}
 }
 
-// Use 使用
+// Names 已添加的配置名，按名称排序
+func Names() []string {
+	optionsMutex.RLock()
+	defer optionsMutex.RUnlock()
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Use 使用，失败时panic
 func Use(name string) *gorm.DB {
+	newGorm, err := Get(context.Background(), name)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return newGorm
+}
+
+// Get 使用，不存在时新建连接，失败时返回错误且不缓存，下次调用重新连接
+func Get(ctx context.Context, name string) (*gorm.DB, error) {
 	if name == "" {
 		name = "default"
 	}
 
 	if instance, ok := pool.Load(name); ok {
-		return instance.(*gorm.DB)
-	} else {
-		mutex.Lock()
-		defer mutex.Unlock()
-		if instance, ok = pool.Load(name); ok {
-			return instance.(*gorm.DB)
-		}
+		return instance.(*gorm.DB), nil
 	}
 
-	option, ok := options[name]
+	mutex.Lock()
+	defer mutex.Unlock()
+	if instance, ok := pool.Load(name); ok {
+		return instance.(*gorm.DB), nil
+	}
+
+	option, ok := getOption(name)
 	if !ok {
-		panic("Option not found " + name)
+		return nil, errors.New("Option not found " + name)
+	}
+
+	newGorm, err := dial(ctx, name, option)
+	if err != nil {
+		return nil, err
 	}
 
+	pool.Store(name, newGorm)
+	return newGorm, nil
+}
+
+// dial 新建连接并Ping，不缓存
+func dial(ctx context.Context, name string, option Option) (*gorm.DB, error) {
 	// 驱动
 	var dialect gorm.Dialector
 	switch option.Driver {
@@ -128,7 +204,7 @@ func Use(name string) *gorm.DB {
 	case "clickhouse":
 		dialect = clickhouse.Open(option.DSN)
 	default:
-		panic("Driver not support " + option.Driver)
+		return nil, errors.New("Driver not support " + option.Driver)
 	}
 
 	gormLogger := logger.New(
@@ -141,14 +217,22 @@ func Use(name string) *gorm.DB {
 		},
 	)
 
-	newGorm, err := gorm.Open(dialect, &gorm.Config{Logger: gormLogger})
-	if err == nil {
-		fmt.Println("Connected to database", name)
-	} else {
-		panic("Failed to connect database " + name + " err: " + err.Error())
+	// 关闭自动Ping，使用ctx Ping
+	newGorm, err := gorm.Open(dialect, &gorm.Config{Logger: gormLogger, DisableAutomaticPing: true})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect database %s err: %w", name, err)
 	}
 
-	sqlDB, _ := newGorm.DB()
+	sqlDB, err := newGorm.DB()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect database %s err: %w", name, err)
+	}
+	if err = sqlDB.PingContext(ctx); err != nil {
+		_ = sqlDB.Close()
+		return nil, fmt.Errorf("Failed to connect database %s err: %w", name, err)
+	}
+	fmt.Println("Connected to database", name)
+
 	// 打开连接的最大数量
 	sqlDB.SetMaxOpenConns(option.MaxOpenConn)
 	// 空闲连接的最大数量
@@ -156,6 +240,32 @@ func Use(name string) *gorm.DB {
 	// 空闲连接的最大存活时间
 	sqlDB.SetConnMaxIdleTime(time.Duration(option.MaxIdleTime) * time.Second)
 
-	pool.Store(name, newGorm)
-	return newGorm
+	return newGorm, nil
+}
+
+// Close 关闭并移除连接，等待执行中的查询完成，下次使用时重新连接，留空使用default
+func Close(name string) error {
+	if name == "" {
+		name = "default"
+	}
+
+	if instance, ok := pool.LoadAndDelete(name); ok {
+		return closeDB(instance)
+	}
+
+	return nil
+}
+
+// CloseAll 关闭所有连接，ctx结束时不再等待
+func CloseAll(ctx context.Context) error {
+	return lifecycle.ClosePool(ctx, pool, closeDB)
+}
+
+func closeDB(instance any) error {
+	sqlDB, err := instance.(*gorm.DB).DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
 }
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"testing"
@@ -346,3 +347,61 @@ func TestLogLevels(t *testing.T) {
 		})
 	}
 }
+
+// TestGet 测试返回错误的使用方式
+func TestGet(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := Get(ctx, "non_existent_db_config"); err == nil {
+		t.Error("配置不存在时应该返回错误")
+	}
+
+	Add("test_get_driver", Option{DSN: "test", Driver: "oracle"})
+	if _, err := Get(ctx, "test_get_driver"); err == nil {
+		t.Error("驱动不支持时应该返回错误")
+	}
+
+	Add("test_get", Option{DSN: ":memory:", Driver: "sqlite"})
+	db, err := Get(ctx, "test_get")
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	if db != Use("test_get") {
+		t.Error("Get 与 Use 应该复用连接")
+	}
+
+	// ctx已结束时连接失败，且不缓存
+	Add("test_get_cancel", Option{DSN: ":memory:", Driver: "sqlite"})
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err = Get(cancelled, "test_get_cancel"); err == nil {
+		t.Error("ctx已结束时应该返回错误")
+	}
+	if _, err = Get(ctx, "test_get_cancel"); err != nil {
+		t.Errorf("重新连接失败: %v", err)
+	}
+}
+
+// TestClose 测试关闭连接
+func TestClose(t *testing.T) {
+	Add("test_close", Option{DSN: ":memory:", Driver: "sqlite"})
+	pool.Delete("test_close")
+
+	db := Use("test_close")
+	if err := Close("test_close"); err != nil {
+		t.Errorf("关闭失败: %v", err)
+	}
+	if sqlDB, _ := db.DB(); sqlDB.Ping() == nil {
+		t.Error("关闭后不应该可用")
+	}
+	if Use("test_close") == db {
+		t.Error("关闭后应该重新连接")
+	}
+
+	if err := CloseAll(context.Background()); err != nil {
+		t.Errorf("关闭所有连接失败: %v", err)
+	}
+	if _, ok := pool.Load("test_close"); ok {
+		t.Error("连接未移除")
+	}
+}
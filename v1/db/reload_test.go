@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestReload 测试DSN变化时替换连接，旧连接关闭
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.db")
+	second := filepath.Join(dir, "second.db")
+
+	Add("test_reload", Option{DSN: first, Driver: "sqlite", LogLevel: 1})
+	t.Cleanup(func() { Close("test_reload") })
+	old := Use("test_reload")
+	old.Exec("CREATE TABLE users (id INTEGER)")
+
+	// 配置未变化时不替换
+	if err := Reload(ctx, "test_reload", Option{DSN: first, Driver: "sqlite", LogLevel: 1}); err != nil {
+		t.Fatalf("重新加载失败: %v", err)
+	}
+	if Use("test_reload") != old {
+		t.Error("配置未变化时不应该替换连接")
+	}
+
+	err := ReloadMapBatch(ctx, map[string]interface{}{
+		"test_reload": map[string]interface{}{"dsn": second, "driver": "sqlite", "log_level": 1},
+	})
+	if err != nil {
+		t.Fatalf("重新加载失败: %v", err)
+	}
+	if Use("test_reload").Migrator().HasTable("users") {
+		t.Error("期望使用新的DSN")
+	}
+
+	sqlDB, _ := old.DB()
+	deadline := time.Now().Add(time.Second)
+	for sqlDB.Ping() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("旧连接未关闭")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestReloadFailed 测试新建失败时保留原配置与连接
+func TestReloadFailed(t *testing.T) {
+	ctx := context.Background()
+	Add("test_reload_failed", Option{DSN: ":memory:", Driver: "sqlite", LogLevel: 1})
+	t.Cleanup(func() { Close("test_reload_failed") })
+	old := Use("test_reload_failed")
+
+	if err := Reload(ctx, "test_reload_failed", Option{DSN: ":memory:", Driver: "oracle"}); err == nil {
+		t.Error("驱动不支持时应该返回错误")
+	}
+	if options["test_reload_failed"].Driver != "sqlite" || Use("test_reload_failed") != old {
+		t.Error("失败时应该保留原配置与连接")
+	}
+	if err := ReloadMap(ctx, "test_reload_failed", map[string]interface{}{}); err == nil {
+		t.Error("缺少DSN时应该返回错误")
+	}
+
+	// 未连接时只更新配置
+	if err := Reload(ctx, "test_reload_new", Option{DSN: ":memory:", Driver: "oracle"}); err != nil {
+		t.Errorf("未连接时不应该新建连接: %v", err)
+	}
+	if _, err := Get(ctx, "test_reload_new"); err == nil {
+		t.Error("驱动不支持时应该返回错误")
+	}
+}
@@ -1,13 +1,20 @@
 package elasticsearch
 
 import (
+	"context"
+	"errors"
+	"sort"
 	"sync"
+
+	"github.com/lynnclub/go/v1/lifecycle"
 )
 
 var (
-	pool    = &sync.Map{}             //实例池
-	mutex   sync.Mutex                //互斥锁
-	options = make(map[string]Option) //配置池
+	pool         = &sync.Map{}             //实例池
+	mutex        sync.Mutex                //互斥锁
+	options      = make(map[string]Option) //配置池
+	optionsMutex sync.RWMutex              //配置池读写锁，Reload 时修改
+	register     sync.Once                 //注册到生命周期管理
 )
 
 type Option struct {
@@ -17,14 +24,49 @@ type Option struct {
 }
 
 func Add(name string, option Option) {
+	option, err := normalize(name, option)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	store(name, option)
+}
+
+// normalize 校验配置
+func normalize(name string, option Option) (Option, error) {
 	if len(option.Address) == 0 {
-		panic("Option address array empty " + name)
+		return option, errors.New("Option address array empty " + name)
 	}
 
+	return option, nil
+}
+
+// store 保存配置，首次保存时注册到生命周期管理
+func store(name string, option Option) {
+	optionsMutex.Lock()
 	options[name] = option
+	optionsMutex.Unlock()
+
+	register.Do(func() {
+		lifecycle.Register(lifecycle.StagePool, "elasticsearch", CloseAll)
+	})
+}
+
+// getOption 读取配置，可与 Reload 并发
+func getOption(name string) (Option, bool) {
+	optionsMutex.RLock()
+	defer optionsMutex.RUnlock()
+
+	option, ok := options[name]
+	return option, ok
 }
 
 func AddMap(name string, setting map[string]interface{}) {
+	Add(name, parseMap(setting))
+}
+
+// parseMap 解析 map 配置
+func parseMap(setting map[string]interface{}) Option {
 	addressStrings := make([]string, 0)
 	for _, v := range setting["address"].([]interface{}) {
 		addressStrings = append(addressStrings, v.(string))
@@ -41,7 +83,7 @@ func AddMap(name string, setting map[string]interface{}) {
 		option.Password = password.(string)
 	}
 
-	Add(name, option)
+	return option
 }
 
 func AddMapBatch(batch map[string]interface{}) {
@@ -49,3 +91,41 @@ func AddMapBatch(batch map[string]interface{}) {
 		AddMap(name, setting.(map[string]interface{}))
 	}
 }
+
+// Names 已添加的配置名，按名称排序
+func Names() []string {
+	optionsMutex.RLock()
+	defer optionsMutex.RUnlock()
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Close 移除实例，HTTP 客户端无需关闭，下次使用时重新创建，留空使用default
+func Close(name string) error {
+	if name == "" {
+		name = "default"
+	}
+
+	for _, instances := range []*sync.Map{pool, poolV7, poolTyped} {
+		instances.Delete(name)
+	}
+
+	return nil
+}
+
+// CloseAll 移除所有实例
+func CloseAll(ctx context.Context) error {
+	remove := func(instance any) error { return nil }
+
+	return errors.Join(
+		lifecycle.ClosePool(ctx, pool, remove),
+		lifecycle.ClosePool(ctx, poolV7, remove),
+		lifecycle.ClosePool(ctx, poolTyped, remove),
+	)
+}
@@ -1,6 +1,7 @@
 package elasticsearch
 
 import (
+	"context"
 	"testing"
 )
 
@@ -198,3 +199,25 @@ func TestOptionOverwrite(t *testing.T) {
 		}
 	}
 }
+
+// TestClose 测试移除实例
+func TestClose(t *testing.T) {
+	pool.Store("test_close", "v8")
+	poolV7.Store("test_close", "v7")
+	poolTyped.Store("other", "typed")
+
+	Close("test_close")
+	if _, ok := pool.Load("test_close"); ok {
+		t.Error("V8实例未移除")
+	}
+	if _, ok := poolV7.Load("test_close"); ok {
+		t.Error("V7实例未移除")
+	}
+
+	if err := CloseAll(context.Background()); err != nil {
+		t.Errorf("移除所有实例失败: %v", err)
+	}
+	if _, ok := poolTyped.Load("other"); ok {
+		t.Error("TypedAPI实例未移除")
+	}
+}
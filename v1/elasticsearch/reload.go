@@ -0,0 +1,92 @@
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Reload 重新加载配置，留空使用default，配置未变化时忽略，不存在时等同 Add
+// 已创建的先按新配置新建客户端并替换，HTTP 客户端无需关闭，处理中的请求不受影响，新建失败时保留原配置与客户端
+func Reload(ctx context.Context, name string, option Option) error {
+	if name == "" {
+		name = "default"
+	}
+
+	option, err := normalize(name, option)
+	if err != nil {
+		return err
+	}
+
+	// 与新建客户端互斥，避免按旧配置新建并缓存
+	mutex.Lock()
+	defer mutex.Unlock()
+	mutexV7.Lock()
+	defer mutexV7.Unlock()
+	mutexTyped.Lock()
+	defer mutexTyped.Unlock()
+
+	if old, ok := getOption(name); ok && reflect.DeepEqual(old, option) {
+		return nil
+	}
+
+	dials := []struct {
+		instances *sync.Map
+		dial      func() (any, error)
+	}{
+		{pool, func() (any, error) { return dialV8(ctx, name, option) }},
+		{poolV7, func() (any, error) { return dialV7(ctx, name, option) }},
+		{poolTyped, func() (any, error) { return dialTypedV8(ctx, name, option) }},
+	}
+
+	replaced := make(map[*sync.Map]any)
+	for _, item := range dials {
+		if _, cached := item.instances.Load(name); !cached {
+			continue
+		}
+
+		client, err := item.dial()
+		if err != nil {
+			return err
+		}
+		replaced[item.instances] = client
+	}
+
+	store(name, option)
+	for instances, client := range replaced {
+		instances.Store(name, client)
+	}
+	fmt.Println("Reloaded elasticsearch", name)
+
+	return nil
+}
+
+// ReloadMap 便捷方法，使用 map
+func ReloadMap(ctx context.Context, name string, setting map[string]interface{}) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("Option invalid %s: %v", name, recovered)
+		}
+	}()
+
+	return Reload(ctx, name, parseMap(setting))
+}
+
+// ReloadMapBatch 便捷方法，使用 map 批量重新加载，单个失败不影响其他，配置中已删除的不处理
+func ReloadMapBatch(ctx context.Context, batch map[string]interface{}) error {
+	var errs []error
+	for name, setting := range batch {
+		setting, ok := setting.(map[string]interface{})
+		if !ok {
+			errs = append(errs, errors.New("Option invalid "+name))
+			continue
+		}
+		if err := ReloadMap(ctx, name, setting); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
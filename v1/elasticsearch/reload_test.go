@@ -0,0 +1,86 @@
+package elasticsearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// newServer 模拟 Elasticsearch，统计请求次数
+func newServer(t *testing.T, requests *atomic.Int64) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"cluster_name":"test","version":{"number":"8.17.0","build_flavor":"default"},"tagline":"You Know, for Search"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// TestReload 测试地址变化时替换客户端
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	var firstRequests, secondRequests atomic.Int64
+	first := newServer(t, &firstRequests)
+	second := newServer(t, &secondRequests)
+
+	Add("test_reload", Option{Address: []string{first.URL}})
+	t.Cleanup(func() { Close("test_reload") })
+	old := UseV8("test_reload")
+
+	// 配置未变化时不替换
+	if err := Reload(ctx, "test_reload", Option{Address: []string{first.URL}}); err != nil {
+		t.Fatalf("重新加载失败: %v", err)
+	}
+	if UseV8("test_reload") != old {
+		t.Error("配置未变化时不应该替换客户端")
+	}
+
+	err := ReloadMap(ctx, "test_reload", map[string]interface{}{
+		"address": []interface{}{second.URL},
+	})
+	if err != nil {
+		t.Fatalf("重新加载失败: %v", err)
+	}
+	if UseV8("test_reload") == old {
+		t.Error("期望替换客户端")
+	}
+	if _, ok := poolV7.Load("test_reload"); ok {
+		t.Error("未创建的客户端不应该新建")
+	}
+
+	before := firstRequests.Load()
+	response, err := UseV8("test_reload").Info()
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	response.Body.Close()
+	if firstRequests.Load() != before || secondRequests.Load() == 0 {
+		t.Error("期望使用新地址")
+	}
+}
+
+// TestReloadFailed 测试新建失败时保留原配置与客户端
+func TestReloadFailed(t *testing.T) {
+	ctx := context.Background()
+	var requests atomic.Int64
+	server := newServer(t, &requests)
+
+	Add("test_reload_failed", Option{Address: []string{server.URL}})
+	t.Cleanup(func() { Close("test_reload_failed") })
+	old := UseV7("test_reload_failed")
+
+	if err := Reload(ctx, "test_reload_failed", Option{Address: []string{"http://127.0.0.1:1"}}); err == nil {
+		t.Error("无法连接时应该返回错误")
+	}
+	if options["test_reload_failed"].Address[0] != server.URL || UseV7("test_reload_failed") != old {
+		t.Error("失败时应该保留原配置与客户端")
+	}
+	if err := ReloadMapBatch(ctx, map[string]interface{}{"test_reload_failed": "invalid"}); err == nil {
+		t.Error("配置格式错误时应该返回错误")
+	}
+}
@@ -1,6 +1,8 @@
 package elasticsearch
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -12,44 +14,63 @@ var (
 	mutexV7 sync.Mutex    //互斥锁
 )
 
-// UseV7 使用V7
+// UseV7 使用V7，失败时panic
 func UseV7(name string) *elasticsearch.Client {
+	client, err := GetV7(context.Background(), name)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return client
+}
+
+// GetV7 使用V7，不存在时新建连接，失败时返回错误且不缓存，下次调用重新连接
+func GetV7(ctx context.Context, name string) (*elasticsearch.Client, error) {
 	if name == "" {
 		name = "default"
 	}
 
 	if instance, ok := poolV7.Load(name); ok {
-		return instance.(*elasticsearch.Client)
-	} else {
-		mutexV7.Lock()
-		defer mutexV7.Unlock()
-		if instance, ok = poolV7.Load(name); ok {
-			return instance.(*elasticsearch.Client)
-		}
+		return instance.(*elasticsearch.Client), nil
+	}
+
+	mutexV7.Lock()
+	defer mutexV7.Unlock()
+	if instance, ok := poolV7.Load(name); ok {
+		return instance.(*elasticsearch.Client), nil
 	}
 
-	option, ok := options[name]
+	option, ok := getOption(name)
 	if !ok {
-		panic("Option not found " + name)
+		return nil, errors.New("Option not found " + name)
+	}
+
+	newClient, err := dialV7(ctx, name, option)
+	if err != nil {
+		return nil, err
 	}
 
+	poolV7.Store(name, newClient)
+	return newClient, nil
+}
+
+// dialV7 新建客户端并请求 Info 检查连接，不缓存
+func dialV7(ctx context.Context, name string, option Option) (*elasticsearch.Client, error) {
 	newClient, err := elasticsearch.NewClient(elasticsearch.Config{
 		Addresses: option.Address,
 		Username:  option.Username,
 		Password:  option.Password,
 	})
 	if err != nil {
-		panic("Failed to new elasticsearch " + name + " err: " + err.Error())
+		return nil, fmt.Errorf("Failed to new elasticsearch %s err: %w", name, err)
 	}
 
-	info, err := newClient.Info()
-	if err == nil {
-		fmt.Println("Connected to elasticsearch", name, info)
-		info.Body.Close()
-	} else {
-		panic("Failed to connect elasticsearch " + name + " err: " + err.Error())
+	info, err := newClient.Info(newClient.Info.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect elasticsearch %s err: %w", name, err)
 	}
+	fmt.Println("Connected to elasticsearch", name, info)
+	info.Body.Close()
 
-	pool.Store(name, newClient)
-	return newClient
+	return newClient, nil
 }
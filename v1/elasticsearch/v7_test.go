@@ -1,6 +1,7 @@
 package elasticsearch
 
 import (
+	"context"
 	"testing"
 )
 
@@ -216,3 +217,19 @@ func TestUseV7ConcurrentAccess(t *testing.T) {
 		}
 	}
 }
+
+// TestGetV7 测试返回错误的使用方式
+func TestGetV7(t *testing.T) {
+	if _, err := GetV7(context.Background(), "nonexistent_get_v7"); err == nil || err.Error() != "Option not found nonexistent_get_v7" {
+		t.Errorf("配置不存在时应该返回错误，实际为 %v", err)
+	}
+
+	// 无法连接时返回错误，不缓存
+	Add("unreachable_v7", Option{Address: []string{"http://127.0.0.1:1"}})
+	if _, err := GetV7(context.Background(), "unreachable_v7"); err == nil {
+		t.Error("无法连接时应该返回错误")
+	}
+	if _, ok := poolV7.Load("unreachable_v7"); ok {
+		t.Error("连接失败不应该缓存")
+	}
+}
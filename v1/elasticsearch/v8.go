@@ -1,49 +1,70 @@
 package elasticsearch
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/elastic/go-elasticsearch/v8"
 )
 
-// UseV8 使用V8
+// UseV8 使用V8，失败时panic
 func UseV8(name string) *elasticsearch.Client {
+	client, err := GetV8(context.Background(), name)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return client
+}
+
+// GetV8 使用V8，不存在时新建连接，失败时返回错误且不缓存，下次调用重新连接
+func GetV8(ctx context.Context, name string) (*elasticsearch.Client, error) {
 	if name == "" {
 		name = "default"
 	}
 
 	if instance, ok := pool.Load(name); ok {
-		return instance.(*elasticsearch.Client)
-	} else {
-		mutex.Lock()
-		defer mutex.Unlock()
-		if instance, ok = pool.Load(name); ok {
-			return instance.(*elasticsearch.Client)
-		}
+		return instance.(*elasticsearch.Client), nil
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if instance, ok := pool.Load(name); ok {
+		return instance.(*elasticsearch.Client), nil
 	}
 
-	option, ok := options[name]
+	option, ok := getOption(name)
 	if !ok {
-		panic("Option not found " + name)
+		return nil, errors.New("Option not found " + name)
 	}
 
+	newClient, err := dialV8(ctx, name, option)
+	if err != nil {
+		return nil, err
+	}
+
+	pool.Store(name, newClient)
+	return newClient, nil
+}
+
+// dialV8 新建客户端并请求 Info 检查连接，不缓存
+func dialV8(ctx context.Context, name string, option Option) (*elasticsearch.Client, error) {
 	newClient, err := elasticsearch.NewClient(elasticsearch.Config{
 		Addresses: option.Address,
 		Username:  option.Username,
 		Password:  option.Password,
 	})
 	if err != nil {
-		panic("Failed to new elasticsearch " + name + " err: " + err.Error())
+		return nil, fmt.Errorf("Failed to new elasticsearch %s err: %w", name, err)
 	}
 
-	info, err := newClient.Info()
-	if err == nil {
-		fmt.Println("Connected to elasticsearch", name, info)
-		info.Body.Close()
-	} else {
-		panic("Failed to connect elasticsearch " + name + " err: " + err.Error())
+	info, err := newClient.Info(newClient.Info.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect elasticsearch %s err: %w", name, err)
 	}
+	fmt.Println("Connected to elasticsearch", name, info)
+	info.Body.Close()
 
-	pool.Store(name, newClient)
-	return newClient
+	return newClient, nil
 }
@@ -1,6 +1,7 @@
 package elasticsearch
 
 import (
+	"context"
 	"testing"
 )
 
@@ -238,3 +239,19 @@ func TestUseV8AfterV7(t *testing.T) {
 	// 再使用 V8 (应该使用不同的连接池)
 	UseV8("test_v7_v8")
 }
+
+// TestGetV8 测试返回错误的使用方式
+func TestGetV8(t *testing.T) {
+	if _, err := GetV8(context.Background(), "nonexistent_get_v8"); err == nil || err.Error() != "Option not found nonexistent_get_v8" {
+		t.Errorf("配置不存在时应该返回错误，实际为 %v", err)
+	}
+
+	// 无法连接时返回错误，不缓存
+	Add("unreachable_v8", Option{Address: []string{"http://127.0.0.1:1"}})
+	if _, err := GetV8(context.Background(), "unreachable_v8"); err == nil {
+		t.Error("无法连接时应该返回错误")
+	}
+	if _, ok := pool.Load("unreachable_v8"); ok {
+		t.Error("连接失败不应该缓存")
+	}
+}
@@ -2,6 +2,7 @@ package elasticsearch
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -13,43 +14,62 @@ var (
 	mutexTyped sync.Mutex    //互斥锁
 )
 
-// TypedV8 使用V8 TypedAPI
+// TypedV8 使用V8 TypedAPI，失败时panic
 func TypedV8(name string) *elasticsearch.TypedClient {
+	client, err := GetTypedV8(context.Background(), name)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return client
+}
+
+// GetTypedV8 使用V8 TypedAPI，不存在时新建连接，失败时返回错误且不缓存，下次调用重新连接
+func GetTypedV8(ctx context.Context, name string) (*elasticsearch.TypedClient, error) {
 	if name == "" {
 		name = "default"
 	}
 
 	if instance, ok := poolTyped.Load(name); ok {
-		return instance.(*elasticsearch.TypedClient)
-	} else {
-		mutexTyped.Lock()
-		defer mutexTyped.Unlock()
-		if instance, ok = poolTyped.Load(name); ok {
-			return instance.(*elasticsearch.TypedClient)
-		}
+		return instance.(*elasticsearch.TypedClient), nil
+	}
+
+	mutexTyped.Lock()
+	defer mutexTyped.Unlock()
+	if instance, ok := poolTyped.Load(name); ok {
+		return instance.(*elasticsearch.TypedClient), nil
 	}
 
-	option, ok := options[name]
+	option, ok := getOption(name)
 	if !ok {
-		panic("Option not found " + name)
+		return nil, errors.New("Option not found " + name)
+	}
+
+	newClient, err := dialTypedV8(ctx, name, option)
+	if err != nil {
+		return nil, err
 	}
 
+	poolTyped.Store(name, newClient)
+	return newClient, nil
+}
+
+// dialTypedV8 新建客户端并请求 Info 检查连接，不缓存
+func dialTypedV8(ctx context.Context, name string, option Option) (*elasticsearch.TypedClient, error) {
 	newClient, err := elasticsearch.NewTypedClient(elasticsearch.Config{
 		Addresses: option.Address,
 		Username:  option.Username,
 		Password:  option.Password,
 	})
 	if err != nil {
-		panic("Failed to new elasticsearch " + name + " err: " + err.Error())
+		return nil, fmt.Errorf("Failed to new elasticsearch %s err: %w", name, err)
 	}
 
-	info, err := newClient.Info().Do(context.Background())
-	if err == nil {
-		fmt.Println("Connected to elasticsearch", name, info)
-	} else {
-		panic("Failed to connect elasticsearch " + name + " err: " + err.Error())
+	info, err := newClient.Info().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect elasticsearch %s err: %w", name, err)
 	}
+	fmt.Println("Connected to elasticsearch", name, info)
 
-	pool.Store(name, newClient)
-	return newClient
+	return newClient, nil
 }
@@ -1,6 +1,7 @@
 package elasticsearch
 
 import (
+	"context"
 	"testing"
 )
 
@@ -270,3 +271,19 @@ func TestTypedV8EmptyNameDefaultsToDefault(t *testing.T) {
 		t.Error("空名称和 'default' 应该返回相同的实例")
 	}
 }
+
+// TestGetTypedV8 测试返回错误的使用方式
+func TestGetTypedV8(t *testing.T) {
+	if _, err := GetTypedV8(context.Background(), "nonexistent_get_typed"); err == nil || err.Error() != "Option not found nonexistent_get_typed" {
+		t.Errorf("配置不存在时应该返回错误，实际为 %v", err)
+	}
+
+	// 无法连接时返回错误，不缓存
+	Add("unreachable_typed", Option{Address: []string{"http://127.0.0.1:1"}})
+	if _, err := GetTypedV8(context.Background(), "unreachable_typed"); err == nil {
+		t.Error("无法连接时应该返回错误")
+	}
+	if _, ok := poolTyped.Load("unreachable_typed"); ok {
+		t.Error("连接失败不应该缓存")
+	}
+}
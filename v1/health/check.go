@@ -0,0 +1,94 @@
+package health
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lynnclub/go/v1/db"
+	"github.com/lynnclub/go/v1/elasticsearch"
+	"github.com/lynnclub/go/v1/mongodb"
+	"github.com/lynnclub/go/v1/rabbit"
+	"github.com/lynnclub/go/v1/redis"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// DB 检查数据库连接
+func DB(name string) Check {
+	return func(ctx context.Context) error {
+		gormDB, err := db.Get(ctx, name)
+		if err != nil {
+			return err
+		}
+		sqlDB, err := gormDB.DB()
+		if err != nil {
+			return err
+		}
+
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// Redis 检查redis连接，各部署模式通用
+func Redis(name string) Check {
+	return func(ctx context.Context) error {
+		client, err := redis.Get(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		return client.Ping(ctx).Err()
+	}
+}
+
+// Mongodb 检查mongodb主节点
+func Mongodb(name string) Check {
+	return func(ctx context.Context) error {
+		client, err := mongodb.Get(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		return client.Ping(ctx, readpref.Primary())
+	}
+}
+
+// Rabbit 检查rabbitmq，每次新建临时连接
+func Rabbit(name string) Check {
+	return func(ctx context.Context) error {
+		return rabbit.Ping(ctx, name)
+	}
+}
+
+// Elasticsearch 检查elasticsearch，使用V7客户端，兼容7.x、8.x
+func Elasticsearch(name string) Check {
+	return func(ctx context.Context) error {
+		client, err := elasticsearch.GetV7(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		response, err := client.Ping(client.Ping.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.IsError() {
+			return errors.New(response.Status())
+		}
+
+		return nil
+	}
+}
+
+// kinds 自动发现的依赖类型
+var kinds = []struct {
+	kind  string
+	names func() []string
+	check func(name string) Check
+}{
+	{"db", db.Names, DB},
+	{"redis", redis.Names, Redis},
+	{"mongodb", mongodb.Names, Mongodb},
+	{"rabbit", rabbit.Names, Rabbit},
+	{"elasticsearch", elasticsearch.Names, Elasticsearch},
+}
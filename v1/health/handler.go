@@ -0,0 +1,40 @@
+package health
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lynnclub/go/v1/response"
+)
+
+// Liveness 使用默认实例的存活探针，用于 /healthz
+func Liveness(c *gin.Context) {
+	Default.Liveness(c)
+}
+
+// Readiness 使用默认实例的就绪探针，用于 /readyz
+func Readiness(c *gin.Context) {
+	Default.Readiness(c)
+}
+
+// Liveness 存活探针，进程能响应即为存活，不检查依赖，避免依赖故障导致重启
+func (checker *Checker) Liveness(c *gin.Context) {
+	response.Json(c, http.StatusOK, "ok", gin.H{
+		"status": StatusUp,
+		"uptime": int64(time.Since(checker.started).Seconds()),
+	})
+}
+
+// Readiness 就绪探针，返回最近一次检查的报告，不实时检查，需先运行 Run
+// 未就绪时 http 状态码与json状态码均为503，负载均衡据此摘除流量
+func (checker *Checker) Readiness(c *gin.Context) {
+	report := checker.Report()
+	if !ready(report) {
+		report.Status = StatusDown
+		response.JsonCode(c, http.StatusServiceUnavailable, http.StatusServiceUnavailable, "not ready", report)
+		return
+	}
+
+	response.JsonCode(c, http.StatusOK, http.StatusOK, "ok", report)
+}
@@ -0,0 +1,262 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lynnclub/go/v1/signal"
+)
+
+// Status 状态
+type Status string
+
+const (
+	StatusUnknown Status = "unknown" //尚未检查
+	StatusUp      Status = "up"      //可用
+	StatusDown    Status = "down"    //不可用
+)
+
+// Check 检查函数，返回错误视为不可用，ctx 超时后应尽快返回
+type Check func(ctx context.Context) error
+
+// Result 单次检查结果
+type Result struct {
+	Status  Status `json:"status"`          //状态
+	Error   string `json:"error,omitempty"` //错误信息
+	Latency int64  `json:"latency"`         //耗时，单位毫秒
+	Time    int64  `json:"time"`            //检查时间，秒级时间戳
+}
+
+// Dependency 依赖状态
+type Dependency struct {
+	Name     string `json:"name"`     //名称，自动发现的格式为 类型:配置名，比如 redis:default
+	Required bool   `json:"required"` //是否必需，必需的依赖不可用时未就绪
+	Result
+	Failures int      `json:"failures"` //连续失败次数
+	History  []Result `json:"history"`  //最近的检查结果，按时间正序
+}
+
+// Report 检查报告
+type Report struct {
+	Status       Status       `json:"status"` //必需的依赖全部可用时为 up
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+type entry struct {
+	check      Check
+	dependency Dependency
+}
+
+// Checker 健康检查，定期检查所有依赖并记录结果
+type Checker struct {
+	Interval    time.Duration //检查间隔，默认10秒
+	Timeout     time.Duration //单次检查超时，默认3秒
+	HistorySize int           //每个依赖保留的历史结果数量，默认10
+
+	entries map[string]*entry
+	mutex   sync.RWMutex
+	started time.Time
+}
+
+// New 实例化
+func New() *Checker {
+	return &Checker{
+		entries: make(map[string]*entry),
+		started: time.Now(),
+	}
+}
+
+// Default 默认实例
+var Default = New()
+
+// Add 使用默认实例添加依赖
+func Add(name string, check Check, required bool) {
+	Default.Add(name, check, required)
+}
+
+// Discover 使用默认实例发现已配置的依赖
+func Discover() {
+	Default.Discover()
+}
+
+// Run 使用默认实例定期检查
+func Run(ctx context.Context) error {
+	return Default.Run(ctx)
+}
+
+// Add 添加依赖，同名覆盖并清空历史
+func (checker *Checker) Add(name string, check Check, required bool) {
+	checker.mutex.Lock()
+	defer checker.mutex.Unlock()
+
+	checker.entries[name] = &entry{
+		check: check,
+		dependency: Dependency{
+			Name:     name,
+			Required: required,
+			Result:   Result{Status: StatusUnknown},
+			History:  []Result{},
+		},
+	}
+}
+
+// Discover 添加 db、redis、mongodb、rabbit、elasticsearch 已配置的所有连接，均为必需依赖
+// 需在各包添加配置之后调用，重复调用会清空已有历史
+func (checker *Checker) Discover() {
+	for _, kind := range kinds {
+		for _, name := range kind.names() {
+			checker.Add(kind.kind+":"+name, kind.check(name), true)
+		}
+	}
+}
+
+// Run 立即检查一次，之后定期检查，阻塞直至ctx结束或收到 signal.Listen 监听的信号
+func (checker *Checker) Run(ctx context.Context) error {
+	ctx, cancel := signal.Context(ctx)
+	defer cancel()
+
+	ticker := time.NewTicker(checker.interval())
+	defer ticker.Stop()
+
+	for {
+		checker.CheckAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll 并发检查所有依赖，记录结果并返回报告
+func (checker *Checker) CheckAll(ctx context.Context) Report {
+	checker.mutex.RLock()
+	entries := make([]*entry, 0, len(checker.entries))
+	for _, item := range checker.entries {
+		entries = append(entries, item)
+	}
+	checker.mutex.RUnlock()
+
+	var wait sync.WaitGroup
+	for _, item := range entries {
+		wait.Add(1)
+		go func(item *entry) {
+			defer wait.Done()
+			checker.record(item, checker.run(ctx, item.check))
+		}(item)
+	}
+	wait.Wait()
+
+	return checker.Report()
+}
+
+// run 带超时执行检查，panic 视为失败
+func (checker *Checker) run(ctx context.Context, check Check) (result Result) {
+	ctx, cancel := context.WithTimeout(ctx, checker.timeout())
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result.Status = StatusDown
+			result.Error = fmt.Sprintf("panic: %v", recovered)
+		}
+		result.Latency = time.Since(start).Milliseconds()
+		result.Time = start.Unix()
+	}()
+
+	if err := check(ctx); err != nil {
+		return Result{Status: StatusDown, Error: err.Error()}
+	}
+
+	return Result{Status: StatusUp}
+}
+
+// record 记录结果，检查期间依赖被覆盖时丢弃
+func (checker *Checker) record(item *entry, result Result) {
+	checker.mutex.Lock()
+	defer checker.mutex.Unlock()
+
+	if checker.entries[item.dependency.Name] != item {
+		return
+	}
+
+	dependency := &item.dependency
+	dependency.Result = result
+	if result.Status == StatusUp {
+		dependency.Failures = 0
+	} else {
+		dependency.Failures++
+	}
+
+	dependency.History = append(dependency.History, result)
+	if overflow := len(dependency.History) - checker.historySize(); overflow > 0 {
+		dependency.History = append([]Result{}, dependency.History[overflow:]...)
+	}
+}
+
+// Report 最近一次检查的报告，按名称排序，不执行检查
+func (checker *Checker) Report() Report {
+	checker.mutex.RLock()
+	defer checker.mutex.RUnlock()
+
+	report := Report{Status: StatusUp, Dependencies: make([]Dependency, 0, len(checker.entries))}
+	for _, item := range checker.entries {
+		dependency := item.dependency
+		dependency.History = append([]Result{}, dependency.History...)
+		report.Dependencies = append(report.Dependencies, dependency)
+
+		if dependency.Required && dependency.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	sort.Slice(report.Dependencies, func(i, j int) bool {
+		return report.Dependencies[i].Name < report.Dependencies[j].Name
+	})
+
+	return report
+}
+
+// Ready 是否就绪，必需的依赖全部可用且未收到 signal.Listen 监听的信号
+// 尚未检查的依赖视为未就绪
+func (checker *Checker) Ready() bool {
+	return ready(checker.Report())
+}
+
+func ready(report Report) bool {
+	select {
+	case <-signal.Done():
+		return false
+	default:
+	}
+
+	return report.Status == StatusUp
+}
+
+func (checker *Checker) interval() time.Duration {
+	if checker.Interval <= 0 {
+		return 10 * time.Second
+	}
+
+	return checker.Interval
+}
+
+func (checker *Checker) timeout() time.Duration {
+	if checker.Timeout <= 0 {
+		return 3 * time.Second
+	}
+
+	return checker.Timeout
+}
+
+func (checker *Checker) historySize() int {
+	if checker.HistorySize <= 0 {
+		return 10
+	}
+
+	return checker.HistorySize
+}
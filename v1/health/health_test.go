@@ -0,0 +1,202 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/lynnclub/go/v1/db"
+	"github.com/lynnclub/go/v1/redis"
+)
+
+// TestCheckAll 测试检查结果、连续失败次数与历史数量
+func TestCheckAll(t *testing.T) {
+	checker := New()
+	checker.HistorySize = 2
+
+	var calls atomic.Int64
+	checker.Add("flaky", func(ctx context.Context) error {
+		if calls.Add(1) <= 2 {
+			return errors.New("refused")
+		}
+		return nil
+	}, true)
+	checker.Add("optional", func(ctx context.Context) error {
+		return errors.New("down")
+	}, false)
+
+	report := checker.Report()
+	if report.Status != StatusDown || report.Dependencies[0].Status != StatusUnknown {
+		t.Errorf("尚未检查时应该未就绪 %+v", report)
+	}
+
+	checker.CheckAll(context.Background())
+	report = checker.CheckAll(context.Background())
+	flaky := report.Dependencies[0]
+	if report.Status != StatusDown || flaky.Status != StatusDown || flaky.Failures != 2 || flaky.Error != "refused" {
+		t.Errorf("期望连续失败2次 %+v", flaky)
+	}
+
+	report = checker.CheckAll(context.Background())
+	flaky = report.Dependencies[0]
+	if report.Status != StatusUp || flaky.Failures != 0 {
+		t.Errorf("非必需依赖不可用不影响就绪 %+v", report)
+	}
+	if len(flaky.History) != 2 || flaky.History[0].Status != StatusDown || flaky.History[1].Status != StatusUp {
+		t.Errorf("历史结果错误 %+v", flaky.History)
+	}
+	if optional := report.Dependencies[1]; optional.Name != "optional" || optional.Failures != 3 {
+		t.Errorf("期望按名称排序且连续失败3次 %+v", optional)
+	}
+}
+
+// TestCheckTimeout 测试超时与panic视为失败
+func TestCheckTimeout(t *testing.T) {
+	checker := New()
+	checker.Timeout = 50 * time.Millisecond
+	checker.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, true)
+	checker.Add("panic", func(ctx context.Context) error {
+		panic("broken")
+	}, true)
+
+	start := time.Now()
+	report := checker.CheckAll(context.Background())
+	if time.Since(start) > time.Second {
+		t.Error("超时未生效")
+	}
+	for _, dependency := range report.Dependencies {
+		if dependency.Status != StatusDown {
+			t.Errorf("期望不可用 %+v", dependency)
+		}
+	}
+	if report.Dependencies[0].Error != "panic: broken" {
+		t.Errorf("期望记录panic %+v", report.Dependencies[0])
+	}
+}
+
+// TestRun 测试定期检查与停止
+func TestRun(t *testing.T) {
+	checker := New()
+	checker.Interval = 10 * time.Millisecond
+
+	var calls atomic.Int64
+	checker.Add("counter", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}, true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- checker.Run(ctx)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run 返回错误: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run 未停止")
+	}
+
+	if calls.Load() < 3 {
+		t.Errorf("期望定期检查，实际检查%d次", calls.Load())
+	}
+	if !checker.Ready() {
+		t.Error("期望就绪")
+	}
+}
+
+// TestDiscover 测试自动发现已配置的连接
+func TestDiscover(t *testing.T) {
+	server := miniredis.RunT(t)
+	redis.Add("health_redis", redis.Option{Address: []string{server.Addr()}})
+	db.Add("health_db", db.Option{DSN: ":memory:", Driver: "sqlite", LogLevel: 1})
+	t.Cleanup(func() {
+		redis.Close("health_redis")
+		db.Close("health_db")
+	})
+
+	checker := New()
+	checker.Discover()
+	report := checker.CheckAll(context.Background())
+
+	found := map[string]Dependency{}
+	for _, dependency := range report.Dependencies {
+		found[dependency.Name] = dependency
+	}
+	for _, name := range []string{"redis:health_redis", "db:health_db"} {
+		if dependency, ok := found[name]; !ok || dependency.Status != StatusUp || !dependency.Required {
+			t.Errorf("%s 期望可用 %+v", name, dependency)
+		}
+	}
+
+	server.Close()
+	checker.CheckAll(context.Background())
+	if checker.Ready() {
+		t.Error("redis 不可用时不应该就绪")
+	}
+}
+
+// TestHandler 测试存活与就绪探针
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	checker := New()
+	var healthy atomic.Bool
+	checker.Add("redis:default", func(ctx context.Context) error {
+		if !healthy.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	}, true)
+
+	router := gin.New()
+	router.GET("/healthz", checker.Liveness)
+	router.GET("/readyz", checker.Readiness)
+
+	request := func(path string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		return w.Code, body
+	}
+
+	checker.CheckAll(context.Background())
+	if code, body := request("/healthz"); code != http.StatusOK || int(body["status"].(float64)) != http.StatusOK {
+		t.Errorf("依赖不可用时仍应存活 %d %v", code, body)
+	}
+
+	code, body := request("/readyz")
+	if code != http.StatusServiceUnavailable || int(body["status"].(float64)) != http.StatusServiceUnavailable {
+		t.Errorf("期望未就绪 %d %v", code, body)
+	}
+	dependencies := body["data"].(map[string]interface{})["dependencies"].([]interface{})
+	detail := dependencies[0].(map[string]interface{})
+	if detail["name"] != "redis:default" || detail["status"] != "down" || detail["error"] != "connection refused" {
+		t.Errorf("依赖详情错误 %v", detail)
+	}
+
+	healthy.Store(true)
+	checker.CheckAll(context.Background())
+	if code, body := request("/readyz"); code != http.StatusOK || body["data"].(map[string]interface{})["status"] != "up" {
+		t.Errorf("期望就绪 %d %v", code, body)
+	}
+}
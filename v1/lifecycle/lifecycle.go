@@ -0,0 +1,211 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lynnclub/go/v1/signal"
+)
+
+// Stage 关闭阶段，按顺序执行，同一阶段内并发执行
+type Stage int
+
+const (
+	StageConsumer Stage = iota // 停止接收新任务，等待处理中的任务完成，比如 HTTP 服务、消费者
+	StagePool                  // 关闭连接池
+	StageLogger                // 刷新日志，最后执行，前面阶段的错误仍可记录
+)
+
+// Hook 关闭函数，ctx 结束时应尽快返回
+type Hook func(ctx context.Context) error
+
+type hook struct {
+	name string
+	fn   Hook
+}
+
+// Manager 生命周期管理，收到信号后按阶段依次关闭
+type Manager struct {
+	Timeout time.Duration // 每个阶段的超时时间，默认10秒
+
+	hooks    map[Stage][]hook
+	mutex    sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	once     sync.Once
+	err      error
+	finished chan struct{}
+}
+
+// New 实例化
+func New() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Manager{
+		hooks:    make(map[Stage][]hook),
+		ctx:      ctx,
+		cancel:   cancel,
+		finished: make(chan struct{}),
+	}
+}
+
+// Default 默认实例，各连接池包添加配置时自动注册
+var Default = New()
+
+// Register 使用默认实例注册关闭函数
+func Register(stage Stage, name string, fn Hook) {
+	Default.Register(stage, name, fn)
+}
+
+// Go 使用默认实例运行常驻任务
+func Go(name string, run func(ctx context.Context) error) {
+	Default.Go(name, run)
+}
+
+// Wait 使用默认实例等待信号并关闭
+func Wait(ctx context.Context) error {
+	return Default.Wait(ctx)
+}
+
+// Shutdown 使用默认实例关闭
+func Shutdown(ctx context.Context) error {
+	return Default.Shutdown(ctx)
+}
+
+// Register 注册关闭函数，同名的后注册覆盖先注册的
+func (m *Manager) Register(stage Stage, name string, fn Hook) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for index, exist := range m.hooks[stage] {
+		if exist.name == name {
+			m.hooks[stage][index].fn = fn
+			return
+		}
+	}
+	m.hooks[stage] = append(m.hooks[stage], hook{name: name, fn: fn})
+}
+
+// Go 运行常驻任务，比如消费者，关闭时先取消 ctx，在 StageConsumer 阶段等待其返回
+func (m *Manager) Go(name string, run func(ctx context.Context) error) {
+	done := make(chan error, 1)
+	go func() {
+		done <- run(m.ctx)
+	}()
+
+	m.Register(StageConsumer, name, func(ctx context.Context) error {
+		select {
+		case err := <-done:
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// Context 关闭开始时取消，可传递给常驻任务
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Wait 阻塞直至收到 signal.Listen 监听的信号或ctx结束，然后关闭，需先调用 signal.Listen
+func (m *Manager) Wait(ctx context.Context) error {
+	select {
+	case <-signal.Done():
+	case <-ctx.Done():
+	case <-m.ctx.Done():
+		// 已在其他地方开始关闭
+		<-m.finished
+		return m.err
+	}
+
+	return m.Shutdown(context.WithoutCancel(ctx))
+}
+
+// Shutdown 按阶段依次关闭，只执行一次，重复调用返回相同结果
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.once.Do(func() {
+		defer close(m.finished)
+		m.cancel()
+
+		m.mutex.Lock()
+		stages := make([]Stage, 0, len(m.hooks))
+		for stage := range m.hooks {
+			stages = append(stages, stage)
+		}
+		m.mutex.Unlock()
+		sort.Slice(stages, func(i, j int) bool { return stages[i] < stages[j] })
+
+		var errs []error
+		for _, stage := range stages {
+			m.mutex.Lock()
+			hooks := append([]hook{}, m.hooks[stage]...)
+			m.mutex.Unlock()
+
+			errs = append(errs, m.run(ctx, hooks)...)
+		}
+		m.err = errors.Join(errs...)
+	})
+
+	<-m.finished
+	return m.err
+}
+
+// run 并发执行同一阶段的关闭函数，超时不等待
+func (m *Manager) run(ctx context.Context, hooks []hook) []error {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout())
+	defer cancel()
+
+	errs := make([]error, len(hooks))
+	var wait sync.WaitGroup
+	for index, hook := range hooks {
+		wait.Add(1)
+		go func(index int) {
+			defer wait.Done()
+
+			done := make(chan error, 1)
+			go func() {
+				done <- call(ctx, hook.fn)
+			}()
+
+			var err error
+			select {
+			case err = <-done:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+			if err != nil {
+				errs[index] = fmt.Errorf("%s: %w", hook.name, err)
+			}
+		}(index)
+	}
+	wait.Wait()
+
+	return errs
+}
+
+// call 调用关闭函数，panic 视为失败
+func call(ctx context.Context, fn Hook) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return fn(ctx)
+}
+
+func (m *Manager) timeout() time.Duration {
+	if m.Timeout <= 0 {
+		return 10 * time.Second
+	}
+
+	return m.Timeout
+}
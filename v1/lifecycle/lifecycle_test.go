@@ -0,0 +1,148 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestShutdownOrder 测试按阶段依次关闭
+func TestShutdownOrder(t *testing.T) {
+	manager := New()
+
+	var mutex sync.Mutex
+	order := []string{}
+	record := func(name string) Hook {
+		return func(ctx context.Context) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	manager.Register(StageLogger, "logger", record("logger"))
+	manager.Register(StagePool, "redis", record("redis"))
+	manager.Register(StageConsumer, "http", record("http"))
+
+	if err := manager.Shutdown(context.Background()); err != nil {
+		t.Fatalf("关闭失败: %v", err)
+	}
+	if strings.Join(order, ",") != "http,redis,logger" {
+		t.Errorf("关闭顺序错误 %v", order)
+	}
+}
+
+// TestShutdownErrors 测试错误、panic、超时均不影响后续阶段
+func TestShutdownErrors(t *testing.T) {
+	manager := New()
+	manager.Timeout = 50 * time.Millisecond
+
+	var flushed atomic.Bool
+	manager.Register(StageConsumer, "slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	manager.Register(StagePool, "failed", func(ctx context.Context) error {
+		return errors.New("close failed")
+	})
+	manager.Register(StagePool, "panic", func(ctx context.Context) error {
+		panic("boom")
+	})
+	manager.Register(StageLogger, "logger", func(ctx context.Context) error {
+		flushed.Store(true)
+		return nil
+	})
+
+	start := time.Now()
+	err := manager.Shutdown(context.Background())
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("超时的关闭函数不应该阻塞")
+	}
+	if !flushed.Load() {
+		t.Error("前面阶段失败不应该影响后续阶段")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望包含超时错误，实际为 %v", err)
+	}
+	for _, message := range []string{"slow:", "failed: close failed", "panic: panic: boom"} {
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("期望错误包含 %q，实际为 %v", message, err)
+		}
+	}
+
+	// 只执行一次，重复调用返回相同结果
+	if again := manager.Shutdown(context.Background()); again != err {
+		t.Error("重复关闭应该返回相同结果")
+	}
+}
+
+// TestRegisterOverwrite 测试同名覆盖
+func TestRegisterOverwrite(t *testing.T) {
+	manager := New()
+
+	var calls atomic.Int32
+	manager.Register(StagePool, "redis", func(ctx context.Context) error {
+		t.Error("被覆盖的关闭函数不应该执行")
+		return nil
+	})
+	manager.Register(StagePool, "redis", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+
+	manager.Shutdown(context.Background())
+	if calls.Load() != 1 {
+		t.Errorf("期望执行1次，实际执行%d次", calls.Load())
+	}
+}
+
+// TestGo 测试常驻任务在关闭时取消，并在连接池关闭前返回
+func TestGo(t *testing.T) {
+	manager := New()
+
+	var stopped atomic.Bool
+	manager.Go("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		stopped.Store(true)
+		return ctx.Err()
+	})
+	manager.Register(StagePool, "redis", func(ctx context.Context) error {
+		if !stopped.Load() {
+			t.Error("常驻任务应该先于连接池停止")
+		}
+		return nil
+	})
+
+	if err := manager.Shutdown(context.Background()); err != nil {
+		t.Errorf("取消导致的返回不应该视为错误: %v", err)
+	}
+}
+
+// TestWait 测试ctx结束时关闭
+func TestWait(t *testing.T) {
+	manager := New()
+
+	var closed atomic.Bool
+	manager.Register(StagePool, "redis", func(ctx context.Context) error {
+		closed.Store(true)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := manager.Wait(ctx); err != nil {
+		t.Errorf("关闭失败: %v", err)
+	}
+	if !closed.Load() {
+		t.Error("Wait 返回前应该已关闭")
+	}
+	if manager.Context().Err() == nil {
+		t.Error("关闭后 Context 应该已取消")
+	}
+}
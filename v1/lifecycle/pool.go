@@ -0,0 +1,46 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ClosePool 关闭并移除实例池中的所有实例，供各连接池包使用
+// ctx 结束时不再等待，剩余实例保留在池中
+func ClosePool(ctx context.Context, pool *sync.Map, closeFunc func(instance any) error) error {
+	var errs []error
+	pool.Range(func(key, _ any) bool {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			return false
+		}
+
+		instance, ok := pool.LoadAndDelete(key)
+		if !ok {
+			return true
+		}
+		if err := CloseWithContext(ctx, instance, closeFunc); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", key, err))
+		}
+		return true
+	})
+
+	return errors.Join(errs...)
+}
+
+// CloseWithContext 关闭实例，ctx 结束时不再等待，关闭在后台继续
+func CloseWithContext(ctx context.Context, instance any, closeFunc func(instance any) error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- closeFunc(instance)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,58 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestClosePool 测试关闭并移除所有实例
+func TestClosePool(t *testing.T) {
+	pool := &sync.Map{}
+	pool.Store("a", "ok")
+	pool.Store("b", "failed")
+
+	var closed atomic.Int32
+	err := ClosePool(context.Background(), pool, func(instance any) error {
+		closed.Add(1)
+		if instance == "failed" {
+			return errors.New("close failed")
+		}
+		return nil
+	})
+
+	if closed.Load() != 2 {
+		t.Errorf("期望关闭2个，实际关闭%d个", closed.Load())
+	}
+	if err == nil || err.Error() != "b: close failed" {
+		t.Errorf("错误信息不正确 %v", err)
+	}
+	pool.Range(func(key, value any) bool {
+		t.Errorf("实例未移除 %v", key)
+		return true
+	})
+}
+
+// TestClosePoolTimeout 测试ctx结束时不再等待
+func TestClosePoolTimeout(t *testing.T) {
+	pool := &sync.Map{}
+	pool.Store("slow", "slow")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := ClosePool(ctx, pool, func(instance any) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("ctx结束时不应该继续等待")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望超时错误，实际为 %v", err)
+	}
+}
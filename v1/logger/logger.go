@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"github.com/lynnclub/go/v1/datetime"
 	"github.com/lynnclub/go/v1/encoding/json"
 	"github.com/lynnclub/go/v1/ip"
+	"github.com/lynnclub/go/v1/lifecycle"
 )
 
 const (
@@ -29,6 +31,12 @@ var (
 	Logger     = New(log.New(os.Stderr, "", log.Lmsgprefix), "local", DEBUG, "asia/shanghai", datetime.LayoutDateTimeZoneT, nil)
 )
 
+func init() {
+	lifecycle.Register(lifecycle.StageLogger, "logger", func(ctx context.Context) error {
+		return Flush()
+	})
+}
+
 type logger struct {
 	Raw        *log.Logger        // 原生log
 	env        string             // 环境
@@ -51,6 +59,11 @@ func New(raw *log.Logger, env string, level int, timezone, timeFormat string, ca
 	}
 }
 
+// LevelName 级别名称，未知级别返回空
+func LevelName(level int) string {
+	return levelFlags[level]
+}
+
 // SetLevel 起始等级
 func (l *logger) SetLevel(level int) {
 	l.level = level
@@ -157,6 +170,28 @@ func (l *logger) Fatalf(format string, v ...interface{}) {
 	l.Fatal(fmt.Sprintf(format, v...))
 }
 
+// Flush 刷新，写入目标实现 Sync 或 Flush 时调用，比如文件、bufio.Writer
+func (l *logger) Flush() error {
+	writer := l.Raw.Writer()
+	if writer == os.Stdout || writer == os.Stderr {
+		return nil
+	}
+
+	switch writer := writer.(type) {
+	case interface{ Sync() error }:
+		return writer.Sync()
+	case interface{ Flush() error }:
+		return writer.Flush()
+	}
+
+	return nil
+}
+
+// Flush 刷新，退出前调用，已注册到 lifecycle 最后阶段
+func Flush() error {
+	return Logger.Flush()
+}
+
 // SetLevel 起始等级
 func SetLevel(level int) {
 	Logger.SetLevel(level)
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"bufio"
 	"bytes"
 	"log"
 	"net/http"
@@ -973,3 +974,26 @@ func TestFeishuAlertSendWithExtra(t *testing.T) {
 		t.Errorf("Expected 1 log in history, got %d", len(alert.lastHashs))
 	}
 }
+
+// TestFlush 测试刷新缓冲
+func TestFlush(t *testing.T) {
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	testLogger := New(log.New(writer, "", log.Lmsgprefix), "local", INFO, "asia/shanghai", datetime.LayoutDateTimeZoneT, nil)
+
+	testLogger.Info("buffered message")
+	if buf.Len() != 0 {
+		t.Fatal("刷新前不应该写入")
+	}
+	if err := testLogger.Flush(); err != nil {
+		t.Errorf("刷新失败: %v", err)
+	}
+	if !strings.Contains(buf.String(), "buffered message") {
+		t.Error("刷新后应该写入")
+	}
+
+	// 标准输出无需刷新
+	if err := Flush(); err != nil {
+		t.Errorf("刷新失败: %v", err)
+	}
+}
@@ -2,19 +2,24 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/lynnclub/go/v1/lifecycle"
 	"go.mongodb.org/mongo-driver/mongo"
 	mongoOptions "go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 var (
-	pool    = &sync.Map{}             //实例池
-	mutex   sync.Mutex                //互斥锁
-	options = make(map[string]Option) //配置池
+	pool         = &sync.Map{}             //实例池
+	mutex        sync.Mutex                //互斥锁
+	options      = make(map[string]Option) //配置池
+	optionsMutex sync.RWMutex              //配置池读写锁，Reload 时修改
+	register     sync.Once                 //注册到生命周期管理
 )
 
 type Option struct {
@@ -22,19 +27,52 @@ type Option struct {
 }
 
 func Add(name string, option Option) {
+	option, err := normalize(name, option)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	store(name, option)
+}
+
+// normalize 校验配置
+func normalize(name string, option Option) (Option, error) {
 	if option.DSN == "" {
-		panic("Option dsn empty " + name)
+		return option, errors.New("Option dsn empty " + name)
 	}
 
+	return option, nil
+}
+
+// store 保存配置，首次保存时注册到生命周期管理
+func store(name string, option Option) {
+	optionsMutex.Lock()
 	options[name] = option
+	optionsMutex.Unlock()
+
+	register.Do(func() {
+		lifecycle.Register(lifecycle.StagePool, "mongodb", CloseAll)
+	})
+}
+
+// getOption 读取配置，可与 Reload 并发
+func getOption(name string) (Option, bool) {
+	optionsMutex.RLock()
+	defer optionsMutex.RUnlock()
+
+	option, ok := options[name]
+	return option, ok
 }
 
 func AddMap(name string, setting map[string]interface{}) {
-	option := Option{
+	Add(name, parseMap(setting))
+}
+
+// parseMap 解析 map 配置
+func parseMap(setting map[string]interface{}) Option {
+	return Option{
 		DSN: setting["dsn"].(string),
 	}
-
-	Add(name, option)
 }
 
 func AddMapBatch(batch map[string]interface{}) {
@@ -43,44 +81,102 @@ func AddMapBatch(batch map[string]interface{}) {
 	}
 }
 
+// Names 已添加的配置名，按名称排序
+func Names() []string {
+	optionsMutex.RLock()
+	defer optionsMutex.RUnlock()
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Use 使用，失败时panic
 func Use(name string) *mongo.Client {
+	client, err := Get(context.Background(), name)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return client
+}
+
+// Get 使用，不存在时新建连接，失败时返回错误且不缓存，下次调用重新连接
+func Get(ctx context.Context, name string) (*mongo.Client, error) {
 	if name == "" {
 		name = "default"
 	}
 
 	if instance, ok := pool.Load(name); ok {
-		return instance.(*mongo.Client)
-	} else {
-		mutex.Lock()
-		defer mutex.Unlock()
-		if instance, ok = pool.Load(name); ok {
-			return instance.(*mongo.Client)
-		}
+		return instance.(*mongo.Client), nil
 	}
 
-	option, ok := options[name]
+	mutex.Lock()
+	defer mutex.Unlock()
+	if instance, ok := pool.Load(name); ok {
+		return instance.(*mongo.Client), nil
+	}
+
+	option, ok := getOption(name)
 	if !ok {
-		panic("Option not found " + name)
+		return nil, errors.New("Option not found " + name)
+	}
+
+	newClient, err := dial(ctx, name, option)
+	if err != nil {
+		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	pool.Store(name, newClient)
+	return newClient, nil
+}
+
+// dial 新建连接并Ping，不缓存
+func dial(ctx context.Context, name string, option Option) (*mongo.Client, error) {
+	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	newClient, err := mongo.Connect(ctx, mongoOptions.Client().ApplyURI(option.DSN))
+	newClient, err := mongo.Connect(connectCtx, mongoOptions.Client().ApplyURI(option.DSN))
 	if err != nil {
-		panic("Failed to connect mongodb " + name + " err: " + err.Error())
+		return nil, fmt.Errorf("Failed to connect mongodb %s err: %w", name, err)
 	}
 
-	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	err = newClient.Ping(ctx, readpref.Primary())
-	if err == nil {
-		fmt.Println("Connected to mongodb", name)
-	} else {
-		panic("Failed to connect mongodb " + name + " err: " + err.Error())
+	if err = newClient.Ping(pingCtx, readpref.Primary()); err != nil {
+		_ = newClient.Disconnect(context.WithoutCancel(ctx))
+		return nil, fmt.Errorf("Failed to connect mongodb %s err: %w", name, err)
 	}
+	fmt.Println("Connected to mongodb", name)
 
-	pool.Store(name, newClient)
-	return newClient
+	return newClient, nil
+}
+
+// Close 关闭并移除连接，下次使用时重新连接，留空使用default
+// 最多等待 DrainTimeout 让执行中的查询完成
+func Close(name string) error {
+	if name == "" {
+		name = "default"
+	}
+
+	if instance, ok := pool.LoadAndDelete(name); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), DrainTimeout)
+		defer cancel()
+
+		return instance.(*mongo.Client).Disconnect(ctx)
+	}
+
+	return nil
+}
+
+// CloseAll 关闭所有连接，ctx结束时不再等待
+func CloseAll(ctx context.Context) error {
+	return lifecycle.ClosePool(ctx, pool, func(instance any) error {
+		return instance.(*mongo.Client).Disconnect(ctx)
+	})
 }
@@ -1,7 +1,9 @@
 package mongodb
 
 import (
+	"context"
 	"testing"
+	"time"
 )
 
 // TestAdd 测试添加配置
@@ -294,3 +296,31 @@ func TestAddWithSSL(t *testing.T) {
 		}
 	}
 }
+
+// TestGet 测试返回错误的使用方式
+func TestGet(t *testing.T) {
+	if _, err := Get(context.Background(), "non_existent"); err == nil {
+		t.Error("配置不存在时应该返回错误")
+	}
+
+	// 无法连接时返回错误，不缓存
+	Add("test_get_unreachable", Option{DSN: "mongodb://127.0.0.1:1"})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := Get(ctx, "test_get_unreachable"); err == nil {
+		t.Error("无法连接时应该返回错误")
+	}
+	if _, ok := pool.Load("test_get_unreachable"); ok {
+		t.Error("连接失败不应该缓存")
+	}
+}
+
+// TestClose 测试关闭不存在的连接
+func TestClose(t *testing.T) {
+	if err := Close("test_close_not_found"); err != nil {
+		t.Errorf("关闭不存在的连接不应该返回错误: %v", err)
+	}
+	if err := CloseAll(context.Background()); err != nil {
+		t.Errorf("关闭所有连接失败: %v", err)
+	}
+}
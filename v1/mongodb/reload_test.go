@@ -0,0 +1,42 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	mongoOptions "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestReload 测试未连接时只更新配置，新建失败时保留原配置与连接
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+
+	if err := Reload(ctx, "test_reload", Option{DSN: "mongodb://127.0.0.1:1"}); err != nil {
+		t.Fatalf("未连接时不应该新建连接: %v", err)
+	}
+	if options["test_reload"].DSN != "mongodb://127.0.0.1:1" {
+		t.Error("配置未保存")
+	}
+	if err := ReloadMap(ctx, "test_reload", map[string]interface{}{}); err == nil {
+		t.Error("缺少DSN时应该返回错误")
+	}
+
+	// 模拟已连接，连接是惰性的，不需要服务
+	old, _ := mongo.Connect(ctx, mongoOptions.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	pool.Store("test_reload", old)
+	t.Cleanup(func() { Close("test_reload") })
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	err := ReloadMapBatch(timeoutCtx, map[string]interface{}{
+		"test_reload": map[string]interface{}{"dsn": "mongodb://127.0.0.1:2"},
+	})
+	if err == nil {
+		t.Error("无法连接时应该返回错误")
+	}
+	if options["test_reload"].DSN != "mongodb://127.0.0.1:1" || Use("test_reload") != old {
+		t.Error("失败时应该保留原配置与连接")
+	}
+}
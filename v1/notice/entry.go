@@ -0,0 +1,123 @@
+package notice
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/lynnclub/go/v1/logger"
+)
+
+// ParseMap 宽松转换为日志结构，缺失字段为零值，兼容json解码出的float64、json.Number、数字字符串
+func ParseMap(log map[string]interface{}) logger.LogEntry {
+	entry := logger.LogEntry{
+		Datetime:  toString(log["datetime"]),
+		Env:       toString(log["env"]),
+		Channel:   toString(log["channel"]),
+		Level:     int(toInt64(log["level"])),
+		LevelName: toString(log["level_name"]),
+		Trace:     toString(log["trace"]),
+		IP:        toString(log["ip"]),
+		Command:   toString(log["command"]),
+		Message:   toString(log["message"]),
+		Context:   toString(log["context"]),
+		Memory:    uint64(toInt64(log["memory"])),
+		Method:    toString(log["method"]),
+		URL:       toString(log["url"]),
+		UserAgent: toString(log["ua"]),
+		Referer:   toString(log["referer"]),
+		Extra:     log["extra"],
+	}
+
+	if entry.LevelName == "" {
+		entry.LevelName = logger.LevelName(entry.Level)
+	}
+
+	// 执行链路统一为 []string，便于去重
+	if extra, ok := entry.Extra.([]interface{}); ok {
+		traces := make([]string, 0, len(extra))
+		for _, trace := range extra {
+			traces = append(traces, toString(trace))
+		}
+		entry.Extra = traces
+	}
+
+	return entry
+}
+
+// ParseJSON 解析一行json日志，忽略json之前的前缀，比如log包输出的时间
+func ParseJSON(line []byte) (logger.LogEntry, error) {
+	if start := bytes.IndexByte(line, '{'); start > 0 {
+		line = line[start:]
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+
+	log := make(map[string]interface{})
+	if err := decoder.Decode(&log); err != nil {
+		return logger.LogEntry{}, err
+	}
+
+	return ParseMap(log), nil
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case json.Number:
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	case map[string]interface{}, []interface{}:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case uint:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case float32:
+		return int64(v)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0
+		}
+		return int64(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return int64(f)
+	case string:
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+		f, _ := strconv.ParseFloat(v, 64)
+		return int64(f)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,76 @@
+package notice
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lynnclub/go/v1/logger"
+)
+
+func TestParseMapLenient(t *testing.T) {
+	entry := ParseMap(map[string]interface{}{
+		"level":   float64(400),
+		"memory":  json.Number("1024"),
+		"message": "test_message",
+		"extra":   []interface{}{"[0] main.main()", "/app/main.go:10"},
+		"context": []interface{}{"a", 1},
+	})
+
+	if entry.Level != logger.ERROR {
+		t.Errorf("Expected level 400, got %d", entry.Level)
+	}
+	if entry.LevelName != "ERROR" {
+		t.Errorf("Expected level name derived from level, got %s", entry.LevelName)
+	}
+	if entry.Memory != 1024 {
+		t.Errorf("Expected memory 1024, got %d", entry.Memory)
+	}
+	if entry.Context != `["a",1]` {
+		t.Errorf("Expected context encoded, got %s", entry.Context)
+	}
+	if traces, ok := entry.Extra.([]string); !ok || traces[0] != "[0] main.main()" {
+		t.Errorf("Expected extra as []string, got %#v", entry.Extra)
+	}
+	if entry.Command != "" || entry.URL != "" {
+		t.Error("Expected missing fields empty")
+	}
+
+	if entry = ParseMap(map[string]interface{}{"level": "250"}); entry.Level != logger.NOTICE {
+		t.Errorf("Expected level 250 from string, got %d", entry.Level)
+	}
+	if entry = ParseMap(nil); entry.Level != 0 {
+		t.Errorf("Expected zero entry, got %+v", entry)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	line := []byte(`2024/05/01 08:30:00 {"datetime":"2024-05-01T08:30:00+08:00","env":"production","level":500,"level_name":"PANIC","command":"./app","message":"panic","url":"","ua":"curl","extra":["[0] main.main()"]}`)
+
+	entry, err := ParseJSON(line)
+	if err != nil {
+		t.Fatalf("ParseJSON failed: %v", err)
+	}
+	if entry.Level != logger.PANIC || entry.LevelName != "PANIC" {
+		t.Errorf("Unexpected level %d %s", entry.Level, entry.LevelName)
+	}
+	if entry.UserAgent != "curl" || entry.Env != "production" {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+
+	if _, err = ParseJSON([]byte("not json")); err == nil {
+		t.Error("Expected error for invalid json")
+	}
+}
+
+func TestSendMissingKeys(t *testing.T) {
+	alert := &FeishuAlert{}
+	alert.Add("default_command", Option{Webhook: "http://127.0.0.1:1"})
+
+	// 缺失字段、float64级别不应该panic
+	alert.Send(map[string]interface{}{"level": float64(400), "message": "missing keys"})
+	alert.Send(map[string]interface{}{})
+
+	if len(alert.lastHashs) != 1 {
+		t.Errorf("Expected 1 log in history, got %d", len(alert.lastHashs))
+	}
+}
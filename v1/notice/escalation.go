@@ -0,0 +1,543 @@
+package notice
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lynnclub/go/v1/bytedance/feishu"
+	"github.com/lynnclub/go/v1/redis"
+	"github.com/lynnclub/go/v1/response"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	KeyEscalation    = redis.KeyBase + "alert:escalation:" //告警升级
+	KeyEscalationDue = KeyEscalation + "due"               //待升级队列，score为到期时间
+)
+
+var (
+	ErrAlertNotFound = errors.New("alert not found")
+	ErrAlertToken    = errors.New("alert token mismatch")
+)
+
+// Escalation 告警升级，超时未确认时发送给下一级值班
+type Escalation struct {
+	redis    string            // redis配置名称
+	ackUrl   string            // 确认接口地址，卡片按钮跳转
+	policies map[string]Policy // 升级策略
+}
+
+// 认领后的重试间隔，升级失败时到期重试
+var escalationRetry = time.Minute
+
+// 认领到期告警，并推迟到重试时间，升级成功后再设置下次到期时间
+// KEYS[1] 待升级队列，ARGV[1] 告警ID，ARGV[2] 当前时间，ARGV[3] 重试时间
+var scriptEscalationClaim = goredis.NewScript(`
+local score = redis.call("ZSCORE", KEYS[1], ARGV[1])
+if not score or tonumber(score) > tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], ARGV[3], ARGV[1])
+return 1`)
+
+// 确认告警，KEYS[1] 告警，ARGV[1] 令牌，ARGV[2] 确认人，ARGV[3] 确认时间
+// 返回 -1 不存在，0 令牌错误，1 成功
+var scriptEscalationAck = goredis.NewScript(`
+local token = redis.call("HGET", KEYS[1], "token")
+if not token then
+	return -1
+end
+if token ~= ARGV[1] then
+	return 0
+end
+redis.call("HSET", KEYS[1], "acked", 1, "acked_by", ARGV[2], "acked_at", ARGV[3])
+return 1`)
+
+// Policy 升级策略
+type Policy struct {
+	AckTimeout time.Duration `json:"ack_timeout"` // 确认超时时间，默认10分钟
+	Timezone   string        `json:"timezone"`    // 排班时区，如 Asia/Shanghai，默认本地时区
+	Tiers      []Tier        `json:"tiers"`       // 值班梯队，按顺序升级
+
+	location *time.Location
+}
+
+// Tier 值班梯队
+type Tier struct {
+	Webhook string   `json:"webhook"`  // 飞书机器人地址，留空沿用告警路由
+	SignKey string   `json:"sign_key"` // 签名密钥
+	UserIds []string `json:"user_ids"` // @用户，不在任何排班时段时使用
+	Shifts  []Shift  `json:"shifts"`   // 排班，按顺序匹配第一个所在时段
+}
+
+// Shift 排班，时段内@指定用户
+type Shift struct {
+	Weekdays []int    `json:"weekdays"` // 星期，0为周日，留空为每天，跨天时段按开始当天计
+	Start    string   `json:"start"`    // 开始时间，如 09:00，默认 00:00
+	End      string   `json:"end"`      // 结束时间，如 18:00，默认 24:00，早于开始时间表示跨天
+	UserIds  []string `json:"user_ids"` // @用户
+}
+
+// NewEscalation 告警升级实例化
+// redisName redis配置名称，ackUrl 确认接口的完整地址，比如 https://ops.example.com/alert/ack
+func NewEscalation(redisName, ackUrl string) *Escalation {
+	return &Escalation{
+		redis:    redisName,
+		ackUrl:   ackUrl,
+		policies: make(map[string]Policy),
+	}
+}
+
+// NewEscalationMap 使用 map 实例化
+func NewEscalationMap(setting map[string]interface{}) *Escalation {
+	redisName, _ := setting["redis"].(string)
+	ackUrl, _ := setting["ack_url"].(string)
+
+	instance := NewEscalation(redisName, ackUrl)
+	if policies, ok := setting["policies"].(map[string]interface{}); ok {
+		instance.AddMapBatch(policies)
+	}
+
+	return instance
+}
+
+func (e *Escalation) Add(name string, policy Policy) {
+	if len(policy.Tiers) == 0 {
+		panic("Policy tiers empty " + name)
+	}
+
+	if policy.AckTimeout <= 0 {
+		policy.AckTimeout = 10 * time.Minute
+	}
+
+	policy.location = time.Local
+	if policy.Timezone != "" {
+		location, err := time.LoadLocation(policy.Timezone)
+		if err != nil {
+			panic("Policy timezone invalid " + name + " " + policy.Timezone)
+		}
+		policy.location = location
+	}
+
+	for _, tier := range policy.Tiers {
+		for _, shift := range tier.Shifts {
+			if _, _, err := shift.minutes(); err != nil {
+				panic("Policy shift invalid " + name + " " + err.Error())
+			}
+		}
+	}
+
+	e.policies[name] = policy
+}
+
+// AddMap 使用 map 添加，ack_timeout 单位分钟
+func (e *Escalation) AddMap(name string, setting map[string]interface{}) {
+	policy := Policy{}
+
+	if ackTimeout, ok := setting["ack_timeout"].(int); ok {
+		policy.AckTimeout = time.Duration(ackTimeout) * time.Minute
+	}
+	policy.Timezone, _ = setting["timezone"].(string)
+
+	if tiers, ok := setting["tiers"].([]interface{}); ok {
+		for _, tmp := range tiers {
+			tierSetting, ok := tmp.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			tier := Tier{}
+			tier.Webhook, _ = tierSetting["webhook"].(string)
+			tier.SignKey, _ = tierSetting["sign_key"].(string)
+			tier.UserIds = toStrings(tierSetting["user_ids"])
+			if shifts, ok := tierSetting["shifts"].([]interface{}); ok {
+				for _, tmp := range shifts {
+					shiftSetting, ok := tmp.(map[string]interface{})
+					if !ok {
+						continue
+					}
+
+					shift := Shift{}
+					shift.Start, _ = shiftSetting["start"].(string)
+					shift.End, _ = shiftSetting["end"].(string)
+					shift.UserIds = toStrings(shiftSetting["user_ids"])
+					if weekdays, ok := shiftSetting["weekdays"].([]interface{}); ok {
+						for _, weekday := range weekdays {
+							if day, ok := weekday.(int); ok {
+								shift.Weekdays = append(shift.Weekdays, day)
+							}
+						}
+					}
+					tier.Shifts = append(tier.Shifts, shift)
+				}
+			}
+			policy.Tiers = append(policy.Tiers, tier)
+		}
+	}
+
+	e.Add(name, policy)
+}
+
+func (e *Escalation) AddMapBatch(batch map[string]interface{}) {
+	for name, setting := range batch {
+		e.AddMap(name, setting.(map[string]interface{}))
+	}
+}
+
+// Trigger 触发告警，先发送给告警路由，超时未确认时按策略逐级升级
+func (e *Escalation) Trigger(ctx context.Context, policyName string, option Option, title, content string) (string, error) {
+	policy, ok := e.policies[policyName]
+	if !ok {
+		return "", errors.New("Policy not found " + policyName)
+	}
+
+	id := randomHex(8)
+	token := randomHex(16)
+	key := alertKey(id)
+
+	client := redis.Universal(e.redis)
+	pipe := client.TxPipeline()
+	pipe.HSet(ctx, key, map[string]interface{}{
+		"policy":   policyName,
+		"webhook":  option.Webhook,
+		"sign_key": option.SignKey,
+		"title":    title,
+		"content":  content,
+		"token":    token,
+		"tier":     0,
+		"acked":    0,
+		"created":  time.Now().Unix(),
+	})
+	// 所有梯队都超时后再保留一个周期，便于查询
+	pipe.Expire(ctx, key, policy.AckTimeout*time.Duration(len(policy.Tiers)+2))
+	pipe.ZAdd(ctx, dueKey(), goredis.Z{
+		Score:  float64(time.Now().Add(policy.AckTimeout).Unix()),
+		Member: id,
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+
+	userIds := []string{}
+	if option.UserId != "" {
+		userIds = append(userIds, option.UserId)
+	}
+
+	robot := feishu.NewGroupRobot(option.Webhook, option.SignKey)
+	_, err := robot.SendContext(ctx, e.card(id, token, 0, title, content, userIds))
+
+	return id, err
+}
+
+// Ack 确认告警，停止升级
+func (e *Escalation) Ack(ctx context.Context, id, token, by string) error {
+	client := redis.Universal(e.redis)
+
+	result, err := scriptEscalationAck.Run(ctx, client, []string{alertKey(id)},
+		token, by, time.Now().Unix()).Int64()
+	if err != nil {
+		return err
+	}
+	switch result {
+	case -1:
+		return ErrAlertNotFound
+	case 0:
+		return ErrAlertToken
+	}
+
+	// 移除失败时，到期后发现已确认也会移除
+	return client.ZRem(ctx, dueKey(), id).Err()
+}
+
+// AckHandler 确认接口，参数 id、token、user
+func (e *Escalation) AckHandler(c *gin.Context) {
+	err := e.Ack(c.Request.Context(), c.Query("id"), c.Query("token"), c.Query("user"))
+	switch {
+	case err == nil:
+		response.Json(c, http.StatusOK, "告警已确认")
+	case errors.Is(err, ErrAlertNotFound):
+		response.Json(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrAlertToken):
+		response.Json(c, http.StatusForbidden, err.Error())
+	default:
+		response.Json(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// Escalate 升级到期未确认的告警，返回升级数量
+// 多实例同时执行时，通过 Lua 原子认领，每条告警只会被一个实例升级
+// 认领时推迟到重试时间而不是移除，升级失败的告警到期后重试，不会丢失
+func (e *Escalation) Escalate(ctx context.Context) (int, error) {
+	return e.escalate(ctx, time.Now())
+}
+
+func (e *Escalation) escalate(ctx context.Context, now time.Time) (int, error) {
+	client := redis.Universal(e.redis)
+
+	ids, err := client.ZRangeByScore(ctx, dueKey(), &goredis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, id := range ids {
+		claimed, err := scriptEscalationClaim.Run(ctx, client, []string{dueKey()},
+			id, now.Unix(), now.Add(escalationRetry).Unix()).Int64()
+		if err != nil {
+			return count, err
+		} else if claimed == 0 {
+			continue
+		}
+
+		ok, err := e.escalateOne(ctx, id, now)
+		switch {
+		case err != nil:
+			// 不经 logger 输出，避免 logger 回调告警时再次进入告警路径
+			println("告警升级失败，稍后重试", id, err.Error())
+		case ok:
+			count++
+		default:
+			// 已确认、已过期或已通知最后一级
+			if err := client.ZRem(ctx, dueKey(), id).Err(); err != nil {
+				return count, err
+			}
+		}
+	}
+
+	return count, nil
+}
+
+func (e *Escalation) escalateOne(ctx context.Context, id string, now time.Time) (bool, error) {
+	key := alertKey(id)
+	client := redis.Universal(e.redis)
+
+	alert, err := client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	if len(alert) == 0 || alert["acked"] == "1" {
+		return false, nil
+	}
+
+	policy, ok := e.policies[alert["policy"]]
+	if !ok {
+		return false, errors.New("Policy not found " + alert["policy"])
+	}
+
+	current, _ := strconv.Atoi(alert["tier"])
+	next := current + 1
+	if next > len(policy.Tiers) {
+		// 已通知最后一级
+		return false, nil
+	}
+
+	tier := policy.Tiers[next-1]
+	webhook, signKey := tier.Webhook, tier.SignKey
+	if webhook == "" {
+		webhook, signKey = alert["webhook"], alert["sign_key"]
+	}
+
+	robot := feishu.NewGroupRobot(webhook, signKey)
+	card := e.card(id, alert["token"], next, alert["title"], alert["content"], tier.users(now.In(policy.location)))
+	if _, err = robot.SendContext(ctx, card); err != nil {
+		return false, err
+	}
+
+	// 发送成功后再推进，发送失败时保持当前梯队，到期重试
+	pipe := client.TxPipeline()
+	pipe.HSet(ctx, key, "tier", next)
+	if next < len(policy.Tiers) {
+		pipe.ZAdd(ctx, dueKey(), goredis.Z{
+			Score:  float64(now.Add(policy.AckTimeout).Unix()),
+			Member: id,
+		})
+	} else {
+		pipe.ZRem(ctx, dueKey(), id)
+	}
+	if _, err = pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// alertKey 告警的键名，加默认构造器的前缀
+func alertKey(id string) string {
+	return redis.NamespaceKey(KeyEscalation + id)
+}
+
+// dueKey 待升级队列的键名，加默认构造器的前缀
+func dueKey() string {
+	return redis.NamespaceKey(KeyEscalationDue)
+}
+
+// users 当前值班的用户，不在任何排班时段时使用 UserIds
+func (tier Tier) users(now time.Time) []string {
+	for _, shift := range tier.Shifts {
+		if shift.active(now) {
+			return shift.UserIds
+		}
+	}
+
+	return tier.UserIds
+}
+
+// active 是否在排班时段内
+func (shift Shift) active(now time.Time) bool {
+	start, end, err := shift.minutes()
+	if err != nil {
+		return false
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	weekday := int(now.Weekday())
+	switch {
+	case start < end:
+		if minute < start || minute >= end {
+			return false
+		}
+	case minute >= start:
+	case minute < end:
+		// 跨天时段的次日部分，按开始当天计
+		weekday = (weekday + 6) % 7
+	default:
+		return false
+	}
+
+	if len(shift.Weekdays) == 0 {
+		return true
+	}
+	for _, day := range shift.Weekdays {
+		if day == weekday {
+			return true
+		}
+	}
+
+	return false
+}
+
+// minutes 开始、结束时间，为当天的分钟数
+func (shift Shift) minutes() (start, end int, err error) {
+	parse := func(value string, fallback int) (int, error) {
+		if value == "" {
+			return fallback, nil
+		}
+		if value == "24:00" {
+			return 24 * 60, nil
+		}
+		parsed, err := time.Parse("15:04", value)
+		if err != nil {
+			return 0, err
+		}
+		return parsed.Hour()*60 + parsed.Minute(), nil
+	}
+
+	if start, err = parse(shift.Start, 0); err != nil {
+		return
+	}
+	end, err = parse(shift.End, 24*60)
+
+	return
+}
+
+// Run 定时升级，阻塞直至ctx结束
+func (e *Escalation) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := e.Escalate(ctx); err != nil {
+				println("告警升级失败", err.Error())
+			}
+		}
+	}
+}
+
+// card 告警卡片，带确认按钮
+func (e *Escalation) card(id, token string, tier int, title, content string, userIds []string) *feishu.GroupRobotRequest {
+	if title == "" {
+		title = "告警"
+	}
+	if tier > 0 {
+		title = fmt.Sprintf("[升级%d] %s", tier, title)
+	}
+
+	mentions := make([]string, 0, len(userIds))
+	for _, userId := range userIds {
+		mentions = append(mentions, "<at id="+userId+"></at>")
+	}
+	if len(mentions) > 0 {
+		content += "\n\n" + strings.Join(mentions, " ")
+	}
+
+	elements := []any{
+		map[string]any{
+			"tag":  "div",
+			"text": map[string]any{"tag": "lark_md", "content": content},
+		},
+	}
+
+	if e.ackUrl != "" {
+		query := url.Values{"id": {id}, "token": {token}}
+		elements = append(elements, map[string]any{
+			"tag": "action",
+			"actions": []any{
+				map[string]any{
+					"tag":  "button",
+					"text": map[string]any{"tag": "plain_text", "content": "确认告警"},
+					"type": "primary",
+					"url":  e.ackUrl + "?" + query.Encode(),
+				},
+			},
+		})
+	}
+
+	request := &feishu.GroupRobotRequest{}
+	return request.BuildCardMessage(map[string]any{
+		"header": map[string]any{
+			"title":    map[string]any{"tag": "plain_text", "content": title},
+			"template": "red",
+		},
+		"elements": elements,
+	})
+}
+
+// randomHex 随机十六进制字符串
+func randomHex(size int) string {
+	bytes := make([]byte, size)
+	_, _ = rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// toStrings 兼容 yaml 解析出的 []interface{}
+func toStrings(value interface{}) []string {
+	result := []string{}
+	switch values := value.(type) {
+	case []string:
+		result = append(result, values...)
+	case []interface{}:
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				result = append(result, s)
+			}
+		}
+	}
+
+	return result
+}
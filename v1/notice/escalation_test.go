@@ -0,0 +1,396 @@
+package notice
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/lynnclub/go/v1/redis"
+)
+
+// feishuRecorder 模拟飞书机器人，记录收到的卡片
+type feishuRecorder struct {
+	mutex sync.Mutex
+	cards []string
+	fail  bool // 返回业务错误，模拟发送失败
+}
+
+func (r *feishuRecorder) server() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		card, _ := json.Marshal(body["card"])
+
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		if r.fail {
+			w.Write([]byte(`{"code":9499,"msg":"Bad Request","data":{}}`))
+			return
+		}
+		r.cards = append(r.cards, string(card))
+
+		w.Write([]byte(`{"code":0,"msg":"success","data":{}}`))
+	}))
+}
+
+func (r *feishuRecorder) setFail(fail bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.fail = fail
+}
+
+func (r *feishuRecorder) last() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.cards) == 0 {
+		return ""
+	}
+	return r.cards[len(r.cards)-1]
+}
+
+func (r *feishuRecorder) count() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.cards)
+}
+
+func setupEscalation(t *testing.T, redisName string) (*miniredis.Miniredis, *Escalation, *feishuRecorder, *httptest.Server) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	redis.Add(redisName, redis.Option{Address: []string{s.Addr()}})
+
+	recorder := &feishuRecorder{}
+	server := recorder.server()
+
+	escalation := NewEscalationMap(map[string]interface{}{
+		"redis":   redisName,
+		"ack_url": "https://ops.example.com/alert/ack",
+		"policies": map[string]interface{}{
+			"oncall": map[string]interface{}{
+				"ack_timeout": 5,
+				"tiers": []interface{}{
+					map[string]interface{}{"user_ids": []interface{}{"ou_tier1"}},
+					map[string]interface{}{"webhook": server.URL, "user_ids": []interface{}{"ou_tier2", "ou_leader"}},
+				},
+			},
+		},
+	})
+
+	return s, escalation, recorder, server
+}
+
+func TestEscalationAddMap(t *testing.T) {
+	escalation := NewEscalationMap(map[string]interface{}{
+		"redis": "default",
+		"policies": map[string]interface{}{
+			"default": map[string]interface{}{
+				"tiers": []interface{}{
+					map[string]interface{}{"user_ids": []interface{}{"ou_1"}},
+				},
+			},
+		},
+	})
+
+	policy, ok := escalation.policies["default"]
+	if !ok {
+		t.Fatal("Expected policy default")
+	}
+	if policy.AckTimeout != 10*time.Minute {
+		t.Errorf("Expected default ack timeout 10m, got %v", policy.AckTimeout)
+	}
+	if len(policy.Tiers) != 1 || policy.Tiers[0].UserIds[0] != "ou_1" {
+		t.Errorf("Unexpected tiers %+v", policy.Tiers)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic when tiers empty")
+		}
+	}()
+	escalation.Add("empty", Policy{})
+}
+
+func TestEscalationEscalate(t *testing.T) {
+	s, escalation, recorder, server := setupEscalation(t, "notice_escalate")
+	defer s.Close()
+	defer server.Close()
+
+	ctx := context.Background()
+	option := Option{Webhook: server.URL, UserId: "ou_owner"}
+	id, err := escalation.Trigger(ctx, "oncall", option, "数据库告警", "连接失败")
+	if err != nil {
+		t.Fatalf("Trigger failed: %v", err)
+	}
+	if recorder.count() != 1 || !strings.Contains(recorder.last(), "ou_owner") {
+		t.Fatalf("Expected first alert to route owner, got %s", recorder.last())
+	}
+	if !strings.Contains(recorder.last(), "id="+id) {
+		t.Errorf("Expected ack button in card, got %s", recorder.last())
+	}
+
+	// 未到期不升级
+	count, err := escalation.escalate(ctx, time.Now())
+	if err != nil || count != 0 {
+		t.Fatalf("Expected no escalation, got %d %v", count, err)
+	}
+
+	// 到期升级到第一级
+	count, _ = escalation.escalate(ctx, time.Now().Add(6*time.Minute))
+	if count != 1 || !strings.Contains(recorder.last(), "ou_tier1") {
+		t.Fatalf("Expected escalation to tier1, got %d %s", count, recorder.last())
+	}
+
+	// 再次到期升级到第二级
+	count, _ = escalation.escalate(ctx, time.Now().Add(12*time.Minute))
+	if count != 1 || !strings.Contains(recorder.last(), "ou_leader") {
+		t.Fatalf("Expected escalation to tier2, got %d %s", count, recorder.last())
+	}
+
+	// 最后一级之后不再升级
+	count, _ = escalation.escalate(ctx, time.Now().Add(time.Hour))
+	if count != 0 {
+		t.Errorf("Expected no more escalation, got %d", count)
+	}
+	if recorder.count() != 3 {
+		t.Errorf("Expected 3 cards, got %d", recorder.count())
+	}
+}
+
+func TestEscalationAck(t *testing.T) {
+	s, escalation, recorder, server := setupEscalation(t, "notice_ack")
+	defer s.Close()
+	defer server.Close()
+
+	ctx := context.Background()
+	id, err := escalation.Trigger(ctx, "oncall", Option{Webhook: server.URL}, "", "磁盘满")
+	if err != nil {
+		t.Fatalf("Trigger failed: %v", err)
+	}
+
+	// 从卡片按钮中取出确认地址
+	card := recorder.last()
+	start := strings.Index(card, "https://ops.example.com/alert/ack?")
+	link := card[start : start+strings.Index(card[start:], `"`)]
+	link = strings.ReplaceAll(link, `\u0026`, "&")
+	parsed, _ := url.Parse(link)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/alert/ack", escalation.AckHandler)
+
+	// token错误
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/alert/ack?id="+id+"&token=wrong", nil)
+	router.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `"status":403`) {
+		t.Errorf("Expected status 403, got %s", w.Body.String())
+	}
+
+	// 不存在
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/alert/ack?id=none&token=none", nil)
+	router.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `"status":404`) {
+		t.Errorf("Expected status 404, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/alert/ack?"+parsed.RawQuery+"&user=lynn", nil)
+	router.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `"status":200`) {
+		t.Fatalf("Expected status 200, got %s", w.Body.String())
+	}
+
+	// 已确认不再升级
+	count, _ := escalation.escalate(ctx, time.Now().Add(6*time.Minute))
+	if count != 0 || recorder.count() != 1 {
+		t.Errorf("Expected no escalation after ack, got %d", count)
+	}
+	if by := s.HGet(KeyEscalation+id, "acked_by"); by != "lynn" {
+		t.Errorf("Expected acked_by lynn, got %s", by)
+	}
+}
+
+func TestEscalationRetry(t *testing.T) {
+	s, escalation, recorder, server := setupEscalation(t, "notice_retry")
+	defer s.Close()
+	defer server.Close()
+
+	ctx := context.Background()
+	id, err := escalation.Trigger(ctx, "oncall", Option{Webhook: server.URL}, "", "队列堆积")
+	if err != nil {
+		t.Fatalf("Trigger failed: %v", err)
+	}
+
+	// 发送失败时不丢失，推迟到重试时间
+	recorder.setFail(true)
+	now := time.Now().Add(6 * time.Minute)
+	count, err := escalation.escalate(ctx, now)
+	if err != nil || count != 0 {
+		t.Fatalf("Expected failed escalation, got %d %v", count, err)
+	}
+	score, err := s.ZScore(KeyEscalationDue, id)
+	if err != nil || int64(score) != now.Add(escalationRetry).Unix() {
+		t.Fatalf("Expected alert rescheduled for retry, got %v %v", score, err)
+	}
+	if tier := s.HGet(KeyEscalation+id, "tier"); tier != "0" {
+		t.Errorf("Expected tier unchanged, got %s", tier)
+	}
+
+	// 重试时间前不重复认领
+	if count, _ = escalation.escalate(ctx, now.Add(time.Second)); count != 0 {
+		t.Errorf("Expected no escalation before retry, got %d", count)
+	}
+
+	recorder.setFail(false)
+	count, _ = escalation.escalate(ctx, now.Add(escalationRetry))
+	if count != 1 || !strings.Contains(recorder.last(), "ou_tier1") {
+		t.Fatalf("Expected retry to tier1, got %d %s", count, recorder.last())
+	}
+}
+
+func TestEscalationAckConcurrent(t *testing.T) {
+	s, escalation, _, server := setupEscalation(t, "notice_ack_concurrent")
+	defer s.Close()
+	defer server.Close()
+
+	ctx := context.Background()
+	id, err := escalation.Trigger(ctx, "oncall", Option{Webhook: server.URL}, "", "")
+	if err != nil {
+		t.Fatalf("Trigger failed: %v", err)
+	}
+	token := s.HGet(KeyEscalation+id, "token")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- escalation.Ack(ctx, id, token, "lynn")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Ack failed: %v", err)
+		}
+	}
+
+	if err := escalation.Ack(ctx, id, "wrong", "lynn"); err != ErrAlertToken {
+		t.Errorf("Expected ErrAlertToken, got %v", err)
+	}
+	if err := escalation.Ack(ctx, "none", token, "lynn"); err != ErrAlertNotFound {
+		t.Errorf("Expected ErrAlertNotFound, got %v", err)
+	}
+	if s.Exists(KeyEscalation + "none") {
+		t.Error("Expected ack not to create missing alert")
+	}
+	if members, _ := s.ZMembers(KeyEscalationDue); len(members) != 0 {
+		t.Errorf("Expected no pending alert, got %v", members)
+	}
+}
+
+func TestEscalationShift(t *testing.T) {
+	tier := Tier{
+		UserIds: []string{"ou_default"},
+		Shifts: []Shift{
+			{Weekdays: []int{1, 2, 3, 4, 5}, Start: "09:00", End: "18:00", UserIds: []string{"ou_day"}},
+			{Start: "22:00", End: "08:00", UserIds: []string{"ou_night"}},
+		},
+	}
+
+	// 2024-05-06 为周一
+	cases := map[string]string{
+		"2024-05-06 10:00": "ou_day",
+		"2024-05-06 18:00": "ou_default",
+		"2024-05-05 10:00": "ou_default",
+		"2024-05-06 23:00": "ou_night",
+		"2024-05-07 07:59": "ou_night",
+		"2024-05-07 08:00": "ou_default",
+	}
+	for now, expected := range cases {
+		parsed, _ := time.Parse("2006-01-02 15:04", now)
+		if users := tier.users(parsed); len(users) != 1 || users[0] != expected {
+			t.Errorf("Expected %s at %s, got %v", expected, now, users)
+		}
+	}
+
+	// 跨天时段的星期按开始当天计
+	weekend := Shift{Weekdays: []int{0}, Start: "20:00", End: "02:00"}
+	monday, _ := time.Parse("2006-01-02 15:04", "2024-05-06 01:00")
+	if !weekend.active(monday) {
+		t.Error("Expected sunday night shift active on monday morning")
+	}
+
+	escalation := NewEscalation("default", "")
+	escalation.AddMap("shift", map[string]interface{}{
+		"timezone": "Asia/Shanghai",
+		"tiers": []interface{}{
+			map[string]interface{}{
+				"user_ids": []interface{}{"ou_default"},
+				"shifts": []interface{}{
+					map[string]interface{}{"weekdays": []interface{}{1, 2}, "start": "09:00", "end": "18:00", "user_ids": []interface{}{"ou_day"}},
+				},
+			},
+		},
+	})
+	policy := escalation.policies["shift"]
+	if policy.location.String() != "Asia/Shanghai" || len(policy.Tiers[0].Shifts[0].Weekdays) != 2 {
+		t.Errorf("Unexpected policy %+v", policy)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic when shift invalid")
+		}
+	}()
+	escalation.Add("invalid", Policy{Tiers: []Tier{{Shifts: []Shift{{Start: "25:00"}}}}})
+}
+
+func TestEscalationTriggerPolicyNotFound(t *testing.T) {
+	escalation := NewEscalation("default", "")
+	if _, err := escalation.Trigger(context.Background(), "none", Option{}, "", ""); err == nil {
+		t.Error("Expected error when policy not found")
+	}
+}
+
+func TestFeishuAlertWithEscalation(t *testing.T) {
+	s, escalation, recorder, server := setupEscalation(t, "notice_alert")
+	defer s.Close()
+	defer server.Close()
+
+	alert := &FeishuAlert{}
+	alert.Add("default_command", Option{Webhook: server.URL, Escalation: "oncall"})
+	alert.SetEscalation(escalation)
+
+	alert.Send(map[string]interface{}{
+		"level":      400,
+		"level_name": "ERROR",
+		"command":    "escalation_command",
+		"message":    "escalation_message",
+		"datetime":   time.Now().Format(time.RFC3339),
+		"trace":      "",
+		"url":        "",
+		"env":        "production",
+		"ip":         "127.0.0.1",
+	})
+
+	if recorder.count() != 1 || !strings.Contains(recorder.last(), "确认告警") {
+		t.Fatalf("Expected escalation card, got %s", recorder.last())
+	}
+	if members, _ := s.ZMembers(KeyEscalationDue); len(members) != 1 {
+		t.Errorf("Expected 1 pending alert, got %d", len(members))
+	}
+}
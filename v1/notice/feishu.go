@@ -1,15 +1,17 @@
 package notice
 
 import (
-	"fmt"
+	"context"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/lynnclub/go/v1/algorithm"
 	"github.com/lynnclub/go/v1/array"
 	"github.com/lynnclub/go/v1/bytedance/feishu"
 	"github.com/lynnclub/go/v1/elasticsearch"
+	"github.com/lynnclub/go/v1/logger"
 	"github.com/lynnclub/go/v1/safe"
 )
 
@@ -23,9 +25,11 @@ func NewFeishu(options map[string]interface{}) *FeishuAlert {
 }
 
 type FeishuAlert struct {
-	options   map[string]Option
-	lastHashs []lastHash // 摘要
-	mutex     sync.Mutex
+	options    map[string]Option
+	lastHashs  []lastHash // 摘要
+	mutex      sync.Mutex
+	escalation *Escalation // 告警升级
+	silence    *Silence    // 告警静默
 }
 
 type lastHash struct {
@@ -34,12 +38,17 @@ type lastHash struct {
 }
 
 type Option struct {
-	Levels    []string `json:"levels"`
-	Webhook   string   `json:"webhook"`
-	SignKey   string   `json:"sign_key"`
-	UserId    string   `json:"user_id"`
-	KibanaUrl string   `json:"kibana_url"`
-	EsIndex   string   `json:"es_index"`
+	Levels     []string `json:"levels"`
+	Webhook    string   `json:"webhook"`
+	SignKey    string   `json:"sign_key"`
+	UserId     string   `json:"user_id"`
+	KibanaUrl  string   `json:"kibana_url"`
+	EsIndex    string   `json:"es_index"`
+	Escalation string   `json:"escalation"` // 升级策略名称，留空不升级
+	Template   string   `json:"template"`   // 内容模板，text/template语法，留空使用内置模板
+	Lang       string   `json:"lang"`       // 内置模板语言，zh、en，默认zh
+
+	tmpl *template.Template // 解析后的模板
 }
 
 func (f *FeishuAlert) Add(name string, option Option) {
@@ -47,6 +56,12 @@ func (f *FeishuAlert) Add(name string, option Option) {
 		panic("Option webhook empty " + name)
 	}
 
+	tmpl, err := parseTemplate(name, option)
+	if err != nil {
+		panic("Option template invalid " + name + " err: " + err.Error())
+	}
+	option.tmpl = tmpl
+
 	if f.options == nil {
 		f.options = make(map[string]Option)
 	}
@@ -56,19 +71,20 @@ func (f *FeishuAlert) Add(name string, option Option) {
 
 func (f *FeishuAlert) AddMap(name string, setting map[string]interface{}) {
 	levels := []string{}
-	if tmps, ok := setting["levels"].([]string); ok {
-		for _, level := range tmps {
-			levels = append(levels, strings.ToUpper(level))
-		}
+	for _, level := range toStrings(setting["levels"]) {
+		levels = append(levels, strings.ToUpper(level))
 	}
 
 	option := Option{
-		Levels:    levels,
-		Webhook:   setting["webhook"].(string),
-		SignKey:   setting["sign_key"].(string),
-		UserId:    setting["user_id"].(string),
-		KibanaUrl: setting["kibana_url"].(string),
-		EsIndex:   setting["es_index"].(string),
+		Levels:     levels,
+		Webhook:    toString(setting["webhook"]),
+		SignKey:    toString(setting["sign_key"]),
+		UserId:     toString(setting["user_id"]),
+		KibanaUrl:  toString(setting["kibana_url"]),
+		EsIndex:    toString(setting["es_index"]),
+		Escalation: toString(setting["escalation"]),
+		Template:   toString(setting["template"]),
+		Lang:       toString(setting["lang"]),
 	}
 
 	f.Add(name, option)
@@ -80,6 +96,16 @@ func (f *FeishuAlert) AddMapBatch(batch map[string]interface{}) {
 	}
 }
 
+// SetEscalation 设置告警升级，路由配置了升级策略时生效
+func (f *FeishuAlert) SetEscalation(escalation *Escalation) {
+	f.escalation = escalation
+}
+
+// SetSilence 设置告警静默
+func (f *FeishuAlert) SetSilence(silence *Silence) {
+	f.silence = silence
+}
+
 func (f *FeishuAlert) FindOption(levelName string, entry, defaultName string) string {
 	for name, option := range f.options {
 		if strings.Contains(entry, name) && (len(option.Levels) == 0 || array.In(option.Levels, levelName)) {
@@ -90,16 +116,22 @@ func (f *FeishuAlert) FindOption(levelName string, entry, defaultName string) st
 	return defaultName
 }
 
+// Send 发送告警，宽松转换 map，缺失字段不会panic
 func (f *FeishuAlert) Send(log map[string]interface{}) {
-	if log["level"].(int) <= 200 {
+	f.SendEntry(ParseMap(log))
+}
+
+// SendEntry 发送告警，INFO及以下级别忽略，可直接作为 logger 回调
+func (f *FeishuAlert) SendEntry(log logger.LogEntry) {
+	if log.Level <= logger.INFO {
 		return
 	}
 
 	name := ""
-	if log["url"].(string) == "" {
-		name = f.FindOption(log["level_name"].(string), log["command"].(string), "default_command")
+	if log.URL == "" {
+		name = f.FindOption(log.LevelName, log.Command, "default_command")
 	} else {
-		name = f.FindOption(log["level_name"].(string), log["url"].(string), "default_api")
+		name = f.FindOption(log.LevelName, log.URL, "default_api")
 	}
 
 	option, ok := f.options[name]
@@ -107,11 +139,15 @@ func (f *FeishuAlert) Send(log map[string]interface{}) {
 		return
 	}
 
+	if f.silence != nil && f.silence.Match(log) {
+		return
+	}
+
 	keyword := ""
-	if traces, ok := log["extra"].([]string); ok && len(traces) > 0 {
+	if traces, ok := log.Extra.([]string); ok && len(traces) > 0 {
 		keyword = traces[0]
 	} else {
-		keyword = log["command"].(string) + log["message"].(string)
+		keyword = log.Command + log.Message
 	}
 
 	if keyword != "" {
@@ -139,51 +175,58 @@ func (f *FeishuAlert) Send(log map[string]interface{}) {
 	}
 
 	safe.Catch(func() {
-		content := f.Format(log, option.KibanaUrl, option.EsIndex)
-		feishu.NewGroupRobot(option.Webhook, option.SignKey).SendRich("", content, option.UserId)
+		content, err := f.Format(log, option)
+		if err != nil {
+			println(err.Error())
+			return
+		}
+
+		// 告警路径不经 logger 输出，避免错误再次触发告警
+		if option.Escalation != "" && f.escalation != nil {
+			_, err = f.escalation.Trigger(context.Background(), option.Escalation, option, "", content)
+		} else {
+			_, err = feishu.NewGroupRobot(option.Webhook, option.SignKey).SendRich("", content, option.UserId)
+		}
+		if err != nil {
+			println(err.Error())
+		}
 	}, func(err any) {
 		println(err)
 	})
 }
 
-func (f *FeishuAlert) Format(log map[string]interface{}, kibanaUrl, esIndex string) string {
+// Format 按路由模板生成告警内容
+func (f *FeishuAlert) Format(log logger.LogEntry, option Option) (string, error) {
+	data := TemplateData{LogEntry: log}
+
 	querys := []string{
-		elasticsearch.GetKuery("message", log["message"].(string)),
+		elasticsearch.GetKuery("message", data.Message),
 	}
 
 	traceParam := ""
-	if trace, ok := log["trace"].(string); !ok || trace == "" {
-		traceParam = elasticsearch.GetKuery("command", log["command"].(string))
-		log["trace"] = log["command"].(string)
+	if data.Trace == "" {
+		traceParam = elasticsearch.GetKuery("command", data.Command)
+		data.Trace = data.Command
 	} else {
-		traceParam = elasticsearch.GetKuery("trace", trace)
+		traceParam = elasticsearch.GetKuery("trace", data.Trace)
 	}
 
 	querys = append(querys, traceParam)
+	data.DetailUrl = elasticsearch.GetKibanaUrl(option.KibanaUrl, option.EsIndex, querys)
+	data.TraceUrl = elasticsearch.GetKibanaUrl(option.KibanaUrl, option.EsIndex, []string{traceParam})
+
+	tmpl := option.tmpl
+	if tmpl == nil {
+		var err error
+		if tmpl, err = parseTemplate("", option); err != nil {
+			return "", err
+		}
+	}
+
+	var content strings.Builder
+	if err := tmpl.Execute(&content, data); err != nil {
+		return "", err
+	}
 
-	return fmt.Sprintf(`环境：%s
-级别：%s
-时间：%s
-IP：%s
-追踪：%s
-入口：%s
-
-%s
-
-详情
-%s
-链路
-%s
-
-如有问题请尽快处理 []~(￣▽￣)~*`,
-		log["env"],
-		log["level_name"],
-		log["datetime"],
-		log["ip"],
-		log["trace"],
-		log["command"],
-		log["message"],
-		elasticsearch.GetKibanaUrl(kibanaUrl, esIndex, querys),
-		elasticsearch.GetKibanaUrl(kibanaUrl, esIndex, []string{traceParam}),
-	)
+	return content.String(), nil
 }
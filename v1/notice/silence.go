@@ -0,0 +1,252 @@
+package notice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lynnclub/go/v1/array"
+	"github.com/lynnclub/go/v1/datetime"
+	"github.com/lynnclub/go/v1/encoding/json"
+	"github.com/lynnclub/go/v1/logger"
+	"github.com/lynnclub/go/v1/redis"
+	"github.com/lynnclub/go/v1/response"
+)
+
+const KeySilence = redis.KeyBase + "alert:silence" //静默规则，hash
+
+var ErrSilenceInvalid = errors.New("silence rule invalid")
+
+// SilenceRule 静默规则，所有非空条件同时满足时静默
+type SilenceRule struct {
+	Id      string   `json:"id"`
+	Env     string   `json:"env"`     // 环境，留空匹配所有
+	Levels  []string `json:"levels"`  // 级别名称，留空匹配所有
+	Message string   `json:"message"` // 消息包含
+	Command string   `json:"command"` // 入口包含，命令或URL
+	Start   string   `json:"start"`   // 开始时间，比如 2024-05-01 22:00:00，留空为当前时间
+	End     string   `json:"end"`     // 结束时间，必填
+	Comment string   `json:"comment"` // 备注
+	Creator string   `json:"creator"` // 创建人
+}
+
+// Match 是否匹配，时间为半闭合区间[start, end)
+func (rule SilenceRule) Match(log logger.LogEntry, timezone string, timestamp int64) bool {
+	if datetime.CheckTime(timestamp, rule.Start, rule.End, timezone) != 1 {
+		return false
+	}
+	if rule.Env != "" && rule.Env != log.Env {
+		return false
+	}
+	if len(rule.Levels) > 0 && !array.In(rule.Levels, log.LevelName) {
+		return false
+	}
+	if rule.Message != "" && !strings.Contains(log.Message, rule.Message) {
+		return false
+	}
+	if rule.Command != "" && !strings.Contains(log.Command, rule.Command) && !strings.Contains(log.URL, rule.Command) {
+		return false
+	}
+
+	return true
+}
+
+// Silence 告警静默，规则保存在 Redis，所有实例共享
+type Silence struct {
+	redis    string        // redis配置名称
+	timezone string        // 时区，用于解析规则的开始结束时间
+	refresh  time.Duration // 本地缓存刷新间隔
+	rules    []SilenceRule // 本地缓存
+	loaded   time.Time     // 缓存加载时间
+	mutex    sync.RWMutex
+}
+
+// NewSilence 告警静默实例化，规则在本地缓存10秒
+func NewSilence(redisName, timezone string) *Silence {
+	return &Silence{
+		redis:    redisName,
+		timezone: timezone,
+		refresh:  10 * time.Second,
+	}
+}
+
+// Add 添加规则
+func (s *Silence) Add(ctx context.Context, rule SilenceRule) (SilenceRule, error) {
+	if rule.Start == "" {
+		rule.Start = datetime.DateTime(s.timezone)
+	}
+	if !validDateTime(rule.Start, s.timezone) || !validDateTime(rule.End, s.timezone) {
+		return rule, ErrSilenceInvalid
+	}
+	if datetime.ToUnix(rule.End, s.timezone) <= datetime.ToUnix(rule.Start, s.timezone) {
+		return rule, ErrSilenceInvalid
+	}
+
+	for key, level := range rule.Levels {
+		rule.Levels[key] = strings.ToUpper(level)
+	}
+	if rule.Id == "" {
+		rule.Id = randomHex(8)
+	}
+
+	if err := redis.Universal(s.redis).HSet(ctx, redis.NamespaceKey(KeySilence), rule.Id, json.Encode(rule)).Err(); err != nil {
+		return rule, err
+	}
+
+	s.expire()
+	return rule, nil
+}
+
+// Delete 删除规则
+func (s *Silence) Delete(ctx context.Context, id string) error {
+	if err := redis.Universal(s.redis).HDel(ctx, redis.NamespaceKey(KeySilence), id).Err(); err != nil {
+		return err
+	}
+
+	s.expire()
+	return nil
+}
+
+// List 规则列表，同时清理已结束的规则
+func (s *Silence) List(ctx context.Context) ([]SilenceRule, error) {
+	rules, ended, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ended) > 0 {
+		client, err := redis.Get(ctx, s.redis)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.HDel(ctx, redis.NamespaceKey(KeySilence), ended...).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return rules, nil
+}
+
+// Match 是否静默，使用本地缓存，过期时从 Redis 刷新
+// 在告警路径中调用，不经 logger 输出，刷新失败时沿用已缓存的规则，并在刷新间隔后重试，从未加载成功时视为不静默
+func (s *Silence) Match(log logger.LogEntry) bool {
+	now := time.Now()
+
+	s.mutex.Lock()
+	rules := s.rules
+	refresh := now.Sub(s.loaded) > s.refresh
+	if refresh {
+		// 先更新加载时间，同一时刻只有一个调用刷新
+		s.loaded = now
+	}
+	s.mutex.Unlock()
+
+	if refresh {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		fresh, _, err := s.load(ctx)
+		cancel()
+		if err != nil {
+			println("告警静默规则刷新失败", err.Error())
+		} else {
+			rules = fresh
+			s.mutex.Lock()
+			s.rules = fresh
+			s.mutex.Unlock()
+		}
+	}
+
+	for _, rule := range rules {
+		if rule.Match(log, s.timezone, now.Unix()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// load 从 Redis 读取规则，返回生效或未开始的规则，以及已结束或无法解析的规则ID，不清理
+func (s *Silence) load(ctx context.Context) (rules []SilenceRule, ended []string, err error) {
+	client, err := redis.Get(ctx, s.redis)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values, err := client.HGetAll(ctx, redis.NamespaceKey(KeySilence)).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().Unix()
+	rules = make([]SilenceRule, 0, len(values))
+	for id, value := range values {
+		var rule SilenceRule
+		if err := json.Decode(value, &rule); err != nil || datetime.CheckTime(now, rule.Start, rule.End, s.timezone) == 2 {
+			ended = append(ended, id)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, ended, nil
+}
+
+// expire 本地缓存失效
+func (s *Silence) expire() {
+	s.mutex.Lock()
+	s.loaded = time.Time{}
+	s.mutex.Unlock()
+}
+
+// ListHandler 规则列表接口
+func (s *Silence) ListHandler(c *gin.Context) {
+	rules, err := s.List(c.Request.Context())
+	if err != nil {
+		response.Json(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Json(c, http.StatusOK, "", rules)
+}
+
+// AddHandler 添加规则接口，json请求体
+func (s *Silence) AddHandler(c *gin.Context) {
+	var rule SilenceRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		response.Json(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule, err := s.Add(c.Request.Context(), rule)
+	if errors.Is(err, ErrSilenceInvalid) {
+		response.Json(c, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		response.Json(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Json(c, http.StatusOK, "", rule)
+}
+
+// DeleteHandler 删除规则接口，路径参数 id
+func (s *Silence) DeleteHandler(c *gin.Context) {
+	if err := s.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		response.Json(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response.Json(c, http.StatusOK, "")
+}
+
+// validDateTime 时间格式是否有效，格式为 datetime.LayoutDateTime 的前缀
+func validDateTime(value, timezone string) bool {
+	if value == "" || len(value) > len(datetime.LayoutDateTime) {
+		return false
+	}
+
+	return !datetime.ParseDateTime(value, timezone).IsZero()
+}
@@ -0,0 +1,227 @@
+package notice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/lynnclub/go/v1/datetime"
+	"github.com/lynnclub/go/v1/logger"
+	"github.com/lynnclub/go/v1/redis"
+)
+
+const silenceTimezone = "Asia/Shanghai"
+
+func setupSilence(t *testing.T, redisName string) (*miniredis.Miniredis, *Silence) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	redis.Add(redisName, redis.Option{Address: []string{s.Addr()}})
+
+	return s, NewSilence(redisName, silenceTimezone)
+}
+
+func TestSilenceRuleMatch(t *testing.T) {
+	rule := SilenceRule{
+		Env:     "production",
+		Levels:  []string{"ERROR"},
+		Message: "timeout",
+		Command: "/api/order",
+		Start:   "2024-05-01 22:00:00",
+		End:     "2024-05-02 02:00:00",
+	}
+	log := logger.LogEntry{Env: "production", LevelName: "ERROR", Message: "db timeout", URL: "https://example.com/api/order/create"}
+	during := datetime.ToUnix("2024-05-01 23:00:00", silenceTimezone)
+
+	if !rule.Match(log, silenceTimezone, during) {
+		t.Error("Expected rule matched")
+	}
+	if rule.Match(log, silenceTimezone, datetime.ToUnix("2024-05-02 02:00:00", silenceTimezone)) {
+		t.Error("Expected rule not matched after end")
+	}
+
+	other := log
+	other.Env = "test"
+	if rule.Match(other, silenceTimezone, during) {
+		t.Error("Expected env not matched")
+	}
+	other = log
+	other.LevelName = "PANIC"
+	if rule.Match(other, silenceTimezone, during) {
+		t.Error("Expected level not matched")
+	}
+	other = log
+	other.Message = "db refused"
+	if rule.Match(other, silenceTimezone, during) {
+		t.Error("Expected message not matched")
+	}
+}
+
+func TestSilenceAddListDelete(t *testing.T) {
+	s, silence := setupSilence(t, "notice_silence")
+	defer s.Close()
+
+	ctx := context.Background()
+	end := datetime.ToDateTime(time.Now().Add(time.Hour).Unix(), silenceTimezone)
+
+	if _, err := silence.Add(ctx, SilenceRule{End: ""}); err != ErrSilenceInvalid {
+		t.Errorf("Expected invalid rule without end, got %v", err)
+	}
+	if _, err := silence.Add(ctx, SilenceRule{Start: "2024-05-02", End: "2024-05-01"}); err != ErrSilenceInvalid {
+		t.Errorf("Expected invalid rule when end before start, got %v", err)
+	}
+
+	rule, err := silence.Add(ctx, SilenceRule{Env: "production", Levels: []string{"error"}, End: end})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if rule.Id == "" || rule.Start == "" || rule.Levels[0] != "ERROR" {
+		t.Errorf("Unexpected rule %+v", rule)
+	}
+
+	// 已结束的规则在列表中被清理
+	silence.Add(ctx, SilenceRule{Id: "ended", Start: "2020-01-01", End: "2020-01-02"})
+
+	rules, err := silence.List(ctx)
+	if err != nil || len(rules) != 1 {
+		t.Fatalf("Expected 1 rule, got %d %v", len(rules), err)
+	}
+	if s.HGet(KeySilence, "ended") != "" {
+		t.Error("Expected ended rule removed")
+	}
+
+	log := logger.LogEntry{Env: "production", LevelName: "ERROR"}
+	if !silence.Match(log) {
+		t.Error("Expected log silenced")
+	}
+
+	// 其他实例共享规则
+	another := NewSilence("notice_silence", silenceTimezone)
+	if !another.Match(log) {
+		t.Error("Expected log silenced by another instance")
+	}
+
+	if err = silence.Delete(ctx, rule.Id); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if silence.Match(log) {
+		t.Error("Expected log not silenced after delete")
+	}
+}
+
+func TestSilenceMatchRedisError(t *testing.T) {
+	s, silence := setupSilence(t, "notice_silence_error")
+
+	ctx := context.Background()
+	end := datetime.ToDateTime(time.Now().Add(time.Hour).Unix(), silenceTimezone)
+	silence.Add(ctx, SilenceRule{End: end})
+	silence.Add(ctx, SilenceRule{Id: "ended", Start: "2020-01-01", End: "2020-01-02"})
+
+	log := logger.LogEntry{Env: "production", LevelName: "ERROR"}
+	if !silence.Match(log) {
+		t.Fatal("Expected log silenced")
+	}
+	// 告警路径不清理已结束的规则
+	if s.HGet(KeySilence, "ended") == "" {
+		t.Error("Expected ended rule kept by match")
+	}
+
+	// 刷新失败时沿用已缓存的规则，且刷新间隔内不再重试
+	s.Close()
+	silence.expire()
+	if !silence.Match(log) {
+		t.Error("Expected cached rules kept when redis unavailable")
+	}
+	silence.mutex.RLock()
+	loaded := silence.loaded
+	silence.mutex.RUnlock()
+	if loaded.IsZero() {
+		t.Error("Expected loaded updated after failed refresh")
+	}
+
+	// 未配置的 redis 不 panic
+	unknown := NewSilence("notice_silence_unknown", silenceTimezone)
+	if unknown.Match(log) {
+		t.Error("Expected not silenced when redis not configured")
+	}
+}
+
+func TestSilenceHandlers(t *testing.T) {
+	s, silence := setupSilence(t, "notice_silence_api")
+	defer s.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/silences", silence.ListHandler)
+	router.POST("/silences", silence.AddHandler)
+	router.DELETE("/silences/:id", silence.DeleteHandler)
+
+	request := func(method, path string, body []byte) map[string]interface{} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		var result map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return result
+	}
+
+	result := request("POST", "/silences", []byte(`{"env":"production"}`))
+	if result["status"].(float64) != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %v", result)
+	}
+
+	end := datetime.ToDateTime(time.Now().Add(time.Hour).Unix(), silenceTimezone)
+	result = request("POST", "/silences", []byte(`{"env":"production","comment":"deploy","end":"`+end+`"}`))
+	if result["status"].(float64) != http.StatusOK {
+		t.Fatalf("Expected status 200, got %v", result)
+	}
+	id := result["data"].(map[string]interface{})["id"].(string)
+
+	result = request("GET", "/silences", nil)
+	if rules := result["data"].([]interface{}); len(rules) != 1 {
+		t.Errorf("Expected 1 rule, got %v", rules)
+	}
+
+	result = request("DELETE", "/silences/"+id, nil)
+	if result["status"].(float64) != http.StatusOK {
+		t.Errorf("Expected status 200, got %v", result)
+	}
+	if s.HGet(KeySilence, id) != "" {
+		t.Error("Expected rule deleted")
+	}
+}
+
+func TestFeishuAlertSilenced(t *testing.T) {
+	s, silence := setupSilence(t, "notice_silence_alert")
+	defer s.Close()
+
+	recorder := &feishuRecorder{}
+	server := recorder.server()
+	defer server.Close()
+
+	alert := &FeishuAlert{}
+	alert.Add("default_command", Option{Webhook: server.URL})
+	alert.SetSilence(silence)
+
+	end := datetime.ToDateTime(time.Now().Add(time.Hour).Unix(), silenceTimezone)
+	silence.Add(context.Background(), SilenceRule{Message: "deploying", End: end})
+
+	alert.SendEntry(logger.LogEntry{Level: logger.ERROR, LevelName: "ERROR", Message: "deploying restart"})
+	if recorder.count() != 0 {
+		t.Errorf("Expected alert silenced, got %d", recorder.count())
+	}
+
+	alert.SendEntry(logger.LogEntry{Level: logger.ERROR, LevelName: "ERROR", Message: "other error"})
+	if recorder.count() != 1 {
+		t.Errorf("Expected alert sent, got %d", recorder.count())
+	}
+}
@@ -0,0 +1,95 @@
+package notice
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/lynnclub/go/v1/datetime"
+	"github.com/lynnclub/go/v1/elasticsearch"
+	"github.com/lynnclub/go/v1/logger"
+)
+
+// TemplateData 模板数据，可使用 LogEntry 所有字段
+type TemplateData struct {
+	logger.LogEntry
+	DetailUrl string // Kibana详情链接，按消息与链路查询
+	TraceUrl  string // Kibana链路链接，按链路查询
+}
+
+// Templates 内置模板，按语言选择
+var Templates = map[string]string{
+	"zh": `环境：{{.Env}}
+级别：{{.LevelName}}
+时间：{{.Datetime}}
+IP：{{.IP}}
+追踪：{{.Trace}}
+入口：{{.Command}}
+
+{{.Message}}
+
+详情
+{{.DetailUrl}}
+链路
+{{.TraceUrl}}
+
+如有问题请尽快处理 []~(￣▽￣)~*`,
+	"en": `Env: {{.Env}}
+Level: {{.LevelName}}
+Time: {{.Datetime}}
+IP: {{.IP}}
+Trace: {{.Trace}}
+Entry: {{.Command}}
+
+{{.Message}}
+
+Details
+{{.DetailUrl}}
+Trace
+{{.TraceUrl}}
+
+Please handle it as soon as possible.`,
+}
+
+// parseTemplate 解析路由模板，未配置时按语言使用内置模板，默认中文
+func parseTemplate(name string, option Option) (*template.Template, error) {
+	text := option.Template
+	if text == "" {
+		var ok bool
+		if text, ok = Templates[option.Lang]; !ok {
+			text = Templates["zh"]
+		}
+	}
+
+	return template.New(name).Funcs(templateFuncs(option)).Parse(text)
+}
+
+// templateFuncs 模板辅助函数
+// kuery 生成查询条件，kibana 生成链接，truncate 截断，formatTime 格式化时间
+func templateFuncs(option Option) template.FuncMap {
+	return template.FuncMap{
+		"kuery": elasticsearch.GetKuery,
+		"kibana": func(querys ...string) string {
+			return elasticsearch.GetKibanaUrl(option.KibanaUrl, option.EsIndex, querys)
+		},
+		"truncate": truncate,
+		"formatTime": func(layout string, value any) string {
+			goTime, err := datetime.ParseAny(value)
+			if err != nil {
+				return ""
+			}
+			return goTime.Format(layout)
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+	}
+}
+
+// truncate 按字符截断，超出部分以...结尾
+func truncate(length int, text string) string {
+	runes := []rune(text)
+	if length <= 0 || len(runes) <= length {
+		return text
+	}
+
+	return string(runes[:length]) + "..."
+}
@@ -0,0 +1,94 @@
+package notice
+
+import (
+	"strings"
+	"testing"
+)
+
+func testLog() map[string]interface{} {
+	return map[string]interface{}{
+		"level":      400,
+		"level_name": "ERROR",
+		"command":    "test_command",
+		"message":    "数据库连接失败，请检查配置",
+		"datetime":   "2024-05-01T08:30:00+08:00",
+		"trace":      "",
+		"url":        "",
+		"env":        "production",
+		"ip":         "127.0.0.1",
+	}
+}
+
+func TestFormatDefaultTemplate(t *testing.T) {
+	alert := &FeishuAlert{}
+	alert.Add("test", Option{Webhook: "http://test.webhook", KibanaUrl: "http://kibana.test", EsIndex: "test_index"})
+
+	content, err := alert.Format(ParseMap(testLog()), alert.options["test"])
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	for _, expected := range []string{"环境：production", "级别：ERROR", "追踪：test_command", "http://kibana.test#/?_a=", "如有问题请尽快处理"} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected content contains %q, got %s", expected, content)
+		}
+	}
+}
+
+func TestFormatEnglishTemplate(t *testing.T) {
+	alert := &FeishuAlert{}
+	alert.Add("test", Option{Webhook: "http://test.webhook", Lang: "en"})
+
+	content, err := alert.Format(ParseMap(testLog()), alert.options["test"])
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	if !strings.HasPrefix(content, "Env: production\nLevel: ERROR") {
+		t.Errorf("Expected english template, got %s", content)
+	}
+}
+
+func TestFormatCustomTemplate(t *testing.T) {
+	alert := &FeishuAlert{}
+	alert.AddMap("test", map[string]interface{}{
+		"webhook":    "http://test.webhook",
+		"sign_key":   "",
+		"user_id":    "",
+		"kibana_url": "http://kibana.test",
+		"es_index":   "test_index",
+		"template":   `[{{upper .Env}}] {{formatTime "15:04" .Datetime}} {{truncate 7 .Message}} {{kibana (kuery "ip" .IP)}}`,
+	})
+
+	content, err := alert.Format(ParseMap(testLog()), alert.options["test"])
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	if !strings.HasPrefix(content, "[PRODUCTION] 08:30 数据库连接失败... http://kibana.test#/?_a=") {
+		t.Errorf("Unexpected content %s", content)
+	}
+}
+
+func TestAddInvalidTemplate(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic when template invalid")
+		}
+	}()
+
+	alert := &FeishuAlert{}
+	alert.Add("test", Option{Webhook: "http://test.webhook", Template: "{{.Message"})
+}
+
+func TestTruncate(t *testing.T) {
+	if result := truncate(3, "abcdef"); result != "abc..." {
+		t.Errorf("Expected abc..., got %s", result)
+	}
+	if result := truncate(10, "abc"); result != "abc" {
+		t.Errorf("Expected abc, got %s", result)
+	}
+	if result := truncate(2, "中文字符"); result != "中文..." {
+		t.Errorf("Expected 中文..., got %s", result)
+	}
+}
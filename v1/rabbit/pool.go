@@ -1,11 +1,17 @@
 package rabbit
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"sort"
 	"sync"
 
+	"github.com/lynnclub/go/v1/lifecycle"
 	"github.com/lynnclub/go/v1/logger"
 	"github.com/lynnclub/go/v1/signal"
+	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/wagslane/go-rabbitmq"
 )
 
@@ -17,6 +23,8 @@ var (
 	mutexPublisher sync.Mutex                //互斥锁
 	mutexConsumer  sync.Mutex                //互斥锁
 	options        = make(map[string]Option) //配置池
+	optionsMutex   sync.RWMutex              //配置池读写锁，Reload 时修改
+	register       sync.Once                 //注册到生命周期管理
 )
 
 type Option struct {
@@ -24,19 +32,54 @@ type Option struct {
 }
 
 func Add(name string, option Option) {
+	option, err := normalize(name, option)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	store(name, option)
+}
+
+// normalize 校验配置
+func normalize(name string, option Option) (Option, error) {
 	if option.DSN == "" {
-		panic("Option dsn empty " + name)
+		return option, errors.New("Option dsn empty " + name)
 	}
 
+	return option, nil
+}
+
+// store 保存配置，首次保存时注册到生命周期管理
+func store(name string, option Option) {
+	optionsMutex.Lock()
 	options[name] = option
+	optionsMutex.Unlock()
+
+	register.Do(func() {
+		// 消费者先于其他连接池关闭，发布者可能在处理消息时使用
+		lifecycle.Register(lifecycle.StageConsumer, "rabbit consumer", CloseConsumers)
+		lifecycle.Register(lifecycle.StagePool, "rabbit", CloseAll)
+	})
+}
+
+// getOption 读取配置，可与 Reload 并发
+func getOption(name string) (Option, bool) {
+	optionsMutex.RLock()
+	defer optionsMutex.RUnlock()
+
+	option, ok := options[name]
+	return option, ok
 }
 
 func AddMap(name string, setting map[string]interface{}) {
-	option := Option{
+	Add(name, parseMap(setting))
+}
+
+// parseMap 解析 map 配置
+func parseMap(setting map[string]interface{}) Option {
+	return Option{
 		DSN: setting["dsn"].(string),
 	}
-
-	Add(name, option)
 }
 
 func AddMapBatch(batch map[string]interface{}) {
@@ -45,35 +88,125 @@ func AddMapBatch(batch map[string]interface{}) {
 	}
 }
 
+// Names 已添加的配置名，按名称排序
+func Names() []string {
+	optionsMutex.RLock()
+	defer optionsMutex.RUnlock()
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Use 使用，失败时panic
 func Use(name string) *rabbitmq.Conn {
+	conn, err := Get(context.Background(), name)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return conn
+}
+
+// Get 使用，不存在时新建连接，失败时返回错误且不缓存，下次调用重新连接
+func Get(ctx context.Context, name string) (*rabbitmq.Conn, error) {
 	if name == "" {
 		name = "default"
 	}
 
 	if instance, ok := pool.Load(name); ok {
-		return instance.(*rabbitmq.Conn)
-	} else {
-		mutex.Lock()
-		defer mutex.Unlock()
-		if instance, ok = pool.Load(name); ok {
-			return instance.(*rabbitmq.Conn)
-		}
+		return instance.(*rabbitmq.Conn), nil
 	}
 
-	option, ok := options[name]
+	mutex.Lock()
+	defer mutex.Unlock()
+	if instance, ok := pool.Load(name); ok {
+		return instance.(*rabbitmq.Conn), nil
+	}
+
+	option, ok := getOption(name)
 	if !ok {
-		panic("Option not found " + name)
+		return nil, errors.New("Option not found " + name)
 	}
 
-	conn, err := rabbitmq.NewConn(option.DSN)
-	if err == nil {
-		fmt.Println("Connected to rabbitmq", name)
-	} else {
-		panic("Failed to connect rabbitmq " + name + " err: " + err.Error())
+	conn, err := dial(ctx, name, option)
+	if err != nil {
+		return nil, err
 	}
 
 	pool.Store(name, conn)
-	return conn
+	return conn, nil
+}
+
+// dial 新建连接，不缓存
+func dial(ctx context.Context, name string, option Option) (*rabbitmq.Conn, error) {
+	// 连接不支持ctx，ctx结束时先返回，连接建立后关闭
+	type result struct {
+		conn *rabbitmq.Conn
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		conn, err := rabbitmq.NewConn(option.DSN)
+		results <- result{conn, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		go func() {
+			if result := <-results; result.conn != nil {
+				_ = result.conn.Close()
+			}
+		}()
+		return nil, fmt.Errorf("Failed to connect rabbitmq %s err: %w", name, ctx.Err())
+	case result := <-results:
+		if result.err != nil {
+			return nil, fmt.Errorf("Failed to connect rabbitmq %s err: %w", name, result.err)
+		}
+		fmt.Println("Connected to rabbitmq", name)
+
+		return result.conn, nil
+	}
+}
+
+// Ping 新建临时连接检查服务是否可用，完成握手后立即关闭，不影响连接池
+// rabbitmq.Conn 断线后自动重连且不暴露状态，无法通过已有连接判断
+func Ping(ctx context.Context, name string) error {
+	if name == "" {
+		name = "default"
+	}
+
+	option, ok := getOption(name)
+	if !ok {
+		return errors.New("Option not found " + name)
+	}
+
+	conn, err := amqp.DialConfig(option.DSN, amqp.Config{
+		Dial: func(network, addr string) (net.Conn, error) {
+			var dialer net.Dialer
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			// 握手超时，握手完成后自动清除
+			if deadline, ok := ctx.Deadline(); ok {
+				if err := conn.SetDeadline(deadline); err != nil {
+					conn.Close()
+					return nil, err
+				}
+			}
+			return conn, nil
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to ping rabbitmq %s err: %w", name, err)
+	}
+
+	return conn.Close()
 }
 
 func GetPublisher(name string, optionFuncs ...func(*rabbitmq.PublisherOptions)) *rabbitmq.Publisher {
@@ -131,3 +264,49 @@ func AsyncCloseConsumer(consumer *rabbitmq.Consumer) {
 		consumer.Close()
 	}()
 }
+
+// Close 关闭并移除连接，依次关闭消费者（等待处理中的消息）、发布者、连接，留空使用default
+func Close(name string) error {
+	if name == "" {
+		name = "default"
+	}
+
+	if instance, ok := poolConsumer.LoadAndDelete(name); ok {
+		instance.(*rabbitmq.Consumer).Close()
+	}
+	if instance, ok := poolPublisher.LoadAndDelete(name); ok {
+		instance.(*rabbitmq.Publisher).Close()
+	}
+	if instance, ok := pool.LoadAndDelete(name); ok {
+		return instance.(*rabbitmq.Conn).Close()
+	}
+
+	return nil
+}
+
+// CloseConsumers 关闭所有消费者，等待处理中的消息，ctx结束时不再等待
+func CloseConsumers(ctx context.Context) error {
+	return lifecycle.ClosePool(ctx, poolConsumer, func(instance any) error {
+		instance.(*rabbitmq.Consumer).CloseWithContext(ctx)
+		return nil
+	})
+}
+
+// CloseAll 依次关闭所有消费者、发布者、连接，ctx结束时不再等待
+func CloseAll(ctx context.Context) error {
+	if err := CloseConsumers(ctx); err != nil {
+		return err
+	}
+
+	err := lifecycle.ClosePool(ctx, poolPublisher, func(instance any) error {
+		instance.(*rabbitmq.Publisher).Close()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return lifecycle.ClosePool(ctx, pool, func(instance any) error {
+		return instance.(*rabbitmq.Conn).Close()
+	})
+}
@@ -0,0 +1,92 @@
+package rabbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/wagslane/go-rabbitmq"
+)
+
+// Reload 重新加载配置，留空使用default，配置未变化时忽略，不存在时等同 Add
+// 已连接的先按新配置新建连接并替换，发布者下次获取时按新连接创建，旧发布者与连接在后台关闭，新建失败时保留原配置与连接
+// 消费者绑定了处理函数，无法自动迁移，存在消费者时返回错误，需重启或 Close 后重新创建
+func Reload(ctx context.Context, name string, option Option) error {
+	if name == "" {
+		name = "default"
+	}
+
+	option, err := normalize(name, option)
+	if err != nil {
+		return err
+	}
+
+	// 与新建连接互斥，避免按旧配置新建并缓存
+	mutex.Lock()
+	defer mutex.Unlock()
+	mutexPublisher.Lock()
+	defer mutexPublisher.Unlock()
+	mutexConsumer.Lock()
+	defer mutexConsumer.Unlock()
+
+	if old, ok := getOption(name); ok && old == option {
+		return nil
+	}
+
+	if _, cached := pool.Load(name); !cached {
+		store(name, option)
+		return nil
+	}
+	if _, running := poolConsumer.Load(name); running {
+		return errors.New("Consumer running, restart required to reload rabbitmq " + name)
+	}
+
+	conn, err := dial(ctx, name, option)
+	if err != nil {
+		return err
+	}
+
+	store(name, option)
+	publisher, hasPublisher := poolPublisher.LoadAndDelete(name)
+	previous, loaded := pool.Swap(name, conn)
+	go func() {
+		// 先关闭发布者，再关闭连接
+		if hasPublisher {
+			publisher.(*rabbitmq.Publisher).Close()
+		}
+		if loaded {
+			_ = previous.(*rabbitmq.Conn).Close()
+		}
+	}()
+	fmt.Println("Reloaded rabbitmq", name)
+
+	return nil
+}
+
+// ReloadMap 便捷方法，使用 map
+func ReloadMap(ctx context.Context, name string, setting map[string]interface{}) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("Option invalid %s: %v", name, recovered)
+		}
+	}()
+
+	return Reload(ctx, name, parseMap(setting))
+}
+
+// ReloadMapBatch 便捷方法，使用 map 批量重新加载，单个失败不影响其他，配置中已删除的不处理
+func ReloadMapBatch(ctx context.Context, batch map[string]interface{}) error {
+	var errs []error
+	for name, setting := range batch {
+		setting, ok := setting.(map[string]interface{})
+		if !ok {
+			errs = append(errs, errors.New("Option invalid "+name))
+			continue
+		}
+		if err := ReloadMap(ctx, name, setting); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,185 @@
+package redis
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 布隆过滤器位图最大位数，redis 字符串最大512MB
+const bloomMaxBits = 1 << 32
+
+// 添加，KEYS[1] 位图，ARGV[1] 每个元素的哈希次数，ARGV[2] 过期时间（毫秒），0为永不过期
+// ARGV[3..] 各元素的位偏移，每个元素 ARGV[1] 个，返回各元素是否新增（至少一位原为0）
+var scriptBloomAdd = redis.NewScript(`
+local hashes = tonumber(ARGV[1])
+local result = {}
+for i = 3, #ARGV, hashes do
+	local added = 0
+	for j = i, i + hashes - 1 do
+		if redis.call("SETBIT", KEYS[1], ARGV[j], 1) == 0 then
+			added = 1
+		end
+	end
+	table.insert(result, added)
+end
+if tonumber(ARGV[2]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return result`)
+
+// 判断，参数同添加，返回各元素是否可能存在（全部位为1）
+var scriptBloomExists = redis.NewScript(`
+local hashes = tonumber(ARGV[1])
+local result = {}
+for i = 3, #ARGV, hashes do
+	local exists = 1
+	for j = i, i + hashes - 1 do
+		if redis.call("GETBIT", KEYS[1], ARGV[j]) == 0 then
+			exists = 0
+			break
+		end
+	end
+	table.insert(result, exists)
+end
+return result`)
+
+// BloomFilter 布隆过滤器，基于位图与 Lua，不依赖 RedisBloom 模块，用于大量元素的去重
+// 判断不存在时一定不存在，判断存在时有 ErrorRate 的概率误判，元素数量超过 Capacity 后误判率上升
+// 位数与哈希次数由 Capacity、ErrorRate 计算，已有数据后不能修改，否则需 Reset
+type BloomFilter struct {
+	Name      string        //名称
+	Client    string        //redis配置名称，留空使用default
+	Capacity  uint64        //预期元素数量，默认100万
+	ErrorRate float64       //误判率，默认0.01
+	Expire    time.Duration //过期时间，添加时重置，默认永不过期
+}
+
+// NewBloomFilter 布隆过滤器实例化
+func NewBloomFilter(name string, capacity uint64, errorRate float64) *BloomFilter {
+	return &BloomFilter{Name: name, Capacity: capacity, ErrorRate: errorRate}
+}
+
+// Add 添加，返回是否新增，false 表示可能已存在
+func (b *BloomFilter) Add(ctx context.Context, item string) (bool, error) {
+	result, err := b.AddMulti(ctx, item)
+	if err != nil {
+		return false, err
+	}
+
+	return result[0], nil
+}
+
+// AddMulti 批量添加，返回各元素是否新增
+func (b *BloomFilter) AddMulti(ctx context.Context, items ...string) ([]bool, error) {
+	return b.run(ctx, scriptBloomAdd, items)
+}
+
+// Exists 是否可能存在，false 表示一定不存在
+func (b *BloomFilter) Exists(ctx context.Context, item string) (bool, error) {
+	result, err := b.ExistsMulti(ctx, item)
+	if err != nil {
+		return false, err
+	}
+
+	return result[0], nil
+}
+
+// ExistsMulti 批量判断是否可能存在
+func (b *BloomFilter) ExistsMulti(ctx context.Context, items ...string) ([]bool, error) {
+	return b.run(ctx, scriptBloomExists, items)
+}
+
+// Reset 清空
+func (b *BloomFilter) Reset(ctx context.Context) error {
+	client, err := Get(ctx, b.Client)
+	if err != nil {
+		return err
+	}
+
+	return client.Del(ctx, b.key()).Err()
+}
+
+// Params 位数与哈希次数
+// 位数 m = -n*ln(p)/(ln2)^2，哈希次数 k = m/n*ln2，位数最多2^32
+func (b *BloomFilter) Params() (bits uint64, hashes int) {
+	capacity := float64(b.capacity())
+	bits = uint64(math.Ceil(-capacity * math.Log(b.errorRate()) / (math.Ln2 * math.Ln2)))
+	bits = min(max(bits, 1), bloomMaxBits)
+	hashes = max(int(math.Round(float64(bits)/capacity*math.Ln2)), 1)
+
+	return bits, hashes
+}
+
+func (b *BloomFilter) run(ctx context.Context, script *redis.Script, items []string) ([]bool, error) {
+	if len(items) == 0 {
+		return nil, errors.New("redis: bloom filter items required")
+	}
+
+	client, err := Get(ctx, b.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	bits, hashes := b.Params()
+	args := make([]interface{}, 0, 2+len(items)*hashes)
+	args = append(args, hashes, b.Expire.Milliseconds())
+	for _, item := range items {
+		for _, offset := range bloomOffsets(item, bits, hashes) {
+			args = append(args, offset)
+		}
+	}
+
+	values, err := script.Run(ctx, client, []string{b.key()}, args...).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]bool, len(values))
+	for index, value := range values {
+		result[index] = value == 1
+	}
+
+	return result, nil
+}
+
+func (b *BloomFilter) key() string {
+	return NamespaceKey(KeyBase + "bloom:" + b.Name)
+}
+
+func (b *BloomFilter) capacity() uint64 {
+	if b.Capacity == 0 {
+		return 1000000
+	}
+
+	return b.Capacity
+}
+
+func (b *BloomFilter) errorRate() float64 {
+	if b.ErrorRate <= 0 || b.ErrorRate >= 1 {
+		return 0.01
+	}
+
+	return b.ErrorRate
+}
+
+// bloomOffsets 双重哈希计算各位偏移，gi(x) = h1(x) + i*h2(x)
+func bloomOffsets(item string, bits uint64, hashes int) []uint64 {
+	hash := fnv.New128a()
+	_, _ = hash.Write([]byte(item))
+	sum := hash.Sum(nil)
+	h1 := binary.BigEndian.Uint64(sum[:8])
+	h2 := binary.BigEndian.Uint64(sum[8:]) | 1
+
+	offsets := make([]uint64, hashes)
+	for i := range offsets {
+		offsets[i] = (h1 + uint64(i)*h2) % bits
+	}
+
+	return offsets
+}
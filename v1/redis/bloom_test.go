@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestBloomFilterParams 测试位数与哈希次数
+func TestBloomFilterParams(t *testing.T) {
+	if bits, hashes := NewBloomFilter("params", 1000, 0.01).Params(); bits != 9586 || hashes != 7 {
+		t.Errorf("期望9586位、7次哈希，实际%d位、%d次", bits, hashes)
+	}
+	if bits, hashes := (&BloomFilter{}).Params(); bits != 9585059 || hashes != 7 {
+		t.Errorf("默认值错误 %d %d", bits, hashes)
+	}
+	if bits, _ := NewBloomFilter("params", 1<<40, 0.0001).Params(); bits != bloomMaxBits {
+		t.Errorf("位数应该不超过2^32，实际%d", bits)
+	}
+}
+
+// TestBloomFilter 测试添加、判断与误判率
+func TestBloomFilter(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	ctx := context.Background()
+	Add("test_bloom", Option{Address: []string{s.Addr()}, SlowThreshold: -1})
+	t.Cleanup(func() { Close("test_bloom") })
+
+	bloom := NewBloomFilter("events", 1000, 0.01)
+	bloom.Client = "test_bloom"
+	bloom.Expire = time.Hour
+
+	if added, err := bloom.Add(ctx, "event:1"); !added || err != nil {
+		t.Fatalf("首次添加应该新增 %v %v", added, err)
+	}
+	if added, _ := bloom.Add(ctx, "event:1"); added {
+		t.Error("重复添加不应该新增")
+	}
+	if s.TTL(bloom.key()) != time.Hour {
+		t.Error("期望设置过期时间")
+	}
+
+	items := make([]string, 1000)
+	for i := range items {
+		items[i] = "item:" + strconv.Itoa(i)
+	}
+	if _, err := bloom.AddMulti(ctx, items...); err != nil {
+		t.Fatalf("批量添加失败: %v", err)
+	}
+	result, err := bloom.ExistsMulti(ctx, items...)
+	if err != nil {
+		t.Fatalf("批量判断失败: %v", err)
+	}
+	for index, exists := range result {
+		if !exists {
+			t.Fatalf("已添加的元素应该存在 %s", items[index])
+		}
+	}
+
+	// 达到容量时误判率接近 ErrorRate
+	var falsePositive int
+	for i := 0; i < 1000; i++ {
+		if exists, _ := bloom.Exists(ctx, "missing:"+strconv.Itoa(i)); exists {
+			falsePositive++
+		}
+	}
+	if falsePositive > 30 {
+		t.Errorf("误判率过高 %d/1000", falsePositive)
+	}
+
+	if err := bloom.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := bloom.Exists(ctx, "event:1"); exists {
+		t.Error("清空后不应该存在")
+	}
+	if _, err := bloom.AddMulti(ctx); err == nil {
+		t.Error("没有元素时应该返回错误")
+	}
+}
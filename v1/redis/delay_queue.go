@@ -0,0 +1,423 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lynnclub/go/v1/logger"
+	"github.com/lynnclub/go/v1/signal"
+	"github.com/redis/go-redis/v9"
+)
+
+// 延迟队列脚本，使用 Redis 服务端时间
+// 任务在 delayed（ZSET，分值为执行时间）、ready（LIST）、processing（ZSET，分值为超时时间）之间流转
+// jobs（HASH）保存任务内容，attempts（HASH）保存执行次数，dead（HASH）保存超过最大执行次数的任务
+var (
+	// 添加，任务ID已存在时忽略，KEYS jobs、delayed，ARGV[1] 任务ID，ARGV[2] 内容，ARGV[3] 延迟毫秒
+	scriptDelayAdd = redis.NewScript(`
+if redis.call("HSETNX", KEYS[1], ARGV[1], ARGV[2]) == 0 then
+	return 0
+end
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call("ZADD", KEYS[2], now + tonumber(ARGV[3]), ARGV[1])
+return 1`)
+
+	// 到期任务与处理超时的任务转入 ready，KEYS delayed、ready、processing，ARGV[1] 单次最大数量
+	scriptDelayPromote = redis.NewScript(`
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local due = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", now, "LIMIT", 0, ARGV[1])
+for _, id in ipairs(due) do
+	redis.call("ZREM", KEYS[1], id)
+	redis.call("LPUSH", KEYS[2], id)
+end
+local expired = redis.call("ZRANGEBYSCORE", KEYS[3], "-inf", now, "LIMIT", 0, ARGV[1])
+for _, id in ipairs(expired) do
+	redis.call("ZREM", KEYS[3], id)
+	redis.call("RPUSH", KEYS[2], id)
+end
+return #due + #expired`)
+
+	// 领取，超过最大执行次数的转入 dead
+	// KEYS ready、processing、jobs、attempts、dead，ARGV[1] 数量，ARGV[2] 处理超时毫秒，ARGV[3] 最大执行次数
+	// 返回 {任务ID, 内容, 第几次执行, ...}
+	scriptDelayReserve = redis.NewScript(`
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local result = {}
+for i = 1, tonumber(ARGV[1]) do
+	local id = redis.call("RPOP", KEYS[1])
+	if not id then
+		break
+	end
+	local payload = redis.call("HGET", KEYS[3], id)
+	if payload then
+		local attempts = redis.call("HINCRBY", KEYS[4], id, 1)
+		if attempts > tonumber(ARGV[3]) then
+			redis.call("HSET", KEYS[5], id, payload)
+			redis.call("HDEL", KEYS[3], id)
+			redis.call("HDEL", KEYS[4], id)
+		else
+			redis.call("ZADD", KEYS[2], now + tonumber(ARGV[2]), id)
+			table.insert(result, id)
+			table.insert(result, payload)
+			table.insert(result, attempts)
+		end
+	end
+end
+return result`)
+
+	// 完成，执行次数不一致说明已超时被重新领取，忽略
+	// KEYS processing、jobs、attempts，ARGV[1] 任务ID，ARGV[2] 第几次执行
+	scriptDelayAck = redis.NewScript(`
+if redis.call("HGET", KEYS[3], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call("ZREM", KEYS[1], ARGV[1])
+redis.call("HDEL", KEYS[2], ARGV[1])
+redis.call("HDEL", KEYS[3], ARGV[1])
+return 1`)
+
+	// 失败，延迟重试或转入 dead
+	// KEYS processing、delayed、jobs、attempts、dead，ARGV[1] 任务ID，ARGV[2] 第几次执行，ARGV[3] 重试延迟毫秒，ARGV[4] 最大执行次数
+	// 返回 0 已被重新领取，1 等待重试，2 转入 dead
+	scriptDelayRetry = redis.NewScript(`
+if redis.call("HGET", KEYS[4], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call("ZREM", KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) >= tonumber(ARGV[4]) then
+	redis.call("HSET", KEYS[5], ARGV[1], redis.call("HGET", KEYS[3], ARGV[1]))
+	redis.call("HDEL", KEYS[3], ARGV[1])
+	redis.call("HDEL", KEYS[4], ARGV[1])
+	return 2
+end
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call("ZADD", KEYS[2], now + tonumber(ARGV[3]), ARGV[1])
+return 1`)
+
+	// 取消，KEYS jobs、attempts、delayed、ready、processing，ARGV[1] 任务ID
+	scriptDelayCancel = redis.NewScript(`
+if redis.call("HDEL", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("HDEL", KEYS[2], ARGV[1])
+redis.call("ZREM", KEYS[3], ARGV[1])
+redis.call("LREM", KEYS[4], 0, ARGV[1])
+redis.call("ZREM", KEYS[5], ARGV[1])
+return 1`)
+)
+
+// DelayJob 延迟任务
+type DelayJob struct {
+	ID       string // 任务ID，队列内唯一
+	Payload  string // 内容
+	Attempts int64  // 第几次执行，从1开始
+}
+
+// DelayHandler 任务处理，返回 nil 时完成，否则延迟重试
+type DelayHandler func(ctx context.Context, job DelayJob) error
+
+// DelayQueueStats 各状态任务数
+type DelayQueueStats struct {
+	Delayed    int64 // 等待到期或重试
+	Ready      int64 // 已到期等待领取
+	Processing int64 // 处理中
+	Dead       int64 // 超过最大执行次数
+}
+
+// DelayQueue 延迟队列，比如30分钟后取消未支付订单
+// 任务ID去重，未完成前重复添加会被忽略；处理超过 Visibility 视为失败，重新投递给其他消费者
+// 至少执行一次，处理函数需幂等
+type DelayQueue struct {
+	Client       string                             // redis配置名称，留空使用default
+	Name         string                             // 队列名称
+	Concurrency  int                                // 并发数，默认10
+	Visibility   time.Duration                      // 处理超时，默认1分钟
+	MaxAttempts  int64                              // 最大执行次数，默认5
+	Backoff      func(attempts int64) time.Duration // 第N次失败后的重试延迟，默认1秒起翻倍，最长1小时
+	PollInterval time.Duration                      // 无任务时的轮询间隔，默认1秒，也是停止的最长等待时间
+	Handler      DelayHandler                       // 任务处理，仅 Run 需要
+
+	wait sync.WaitGroup
+	sem  chan struct{}
+}
+
+// NewDelayQueue 延迟队列实例化，只添加任务时 handler 传 nil
+func NewDelayQueue(name string, handler DelayHandler) *DelayQueue {
+	return &DelayQueue{Name: name, Handler: handler}
+}
+
+// Add 添加任务，delay 后执行，任务ID已存在时返回 false
+func (q *DelayQueue) Add(ctx context.Context, id, payload string, delay time.Duration) (bool, error) {
+	if q.Name == "" || id == "" {
+		return false, errors.New("redis: delay queue name and job id required")
+	}
+
+	client, err := Get(ctx, q.Client)
+	if err != nil {
+		return false, err
+	}
+
+	added, err := scriptDelayAdd.Run(ctx, client,
+		[]string{q.key("jobs"), q.key("delayed")},
+		id, payload, max(delay.Milliseconds(), 0)).Int()
+
+	return added == 1, err
+}
+
+// AddAt 添加任务，at 时执行，任务ID已存在时返回 false
+func (q *DelayQueue) AddAt(ctx context.Context, id, payload string, at time.Time) (bool, error) {
+	return q.Add(ctx, id, payload, time.Until(at))
+}
+
+// Cancel 取消任务，任务不存在或已完成时返回 false；处理中的任务无法中断，但不会再重试
+func (q *DelayQueue) Cancel(ctx context.Context, id string) (bool, error) {
+	client, err := Get(ctx, q.Client)
+	if err != nil {
+		return false, err
+	}
+
+	cancelled, err := scriptDelayCancel.Run(ctx, client,
+		[]string{q.key("jobs"), q.key("attempts"), q.key("delayed"), q.key("ready"), q.key("processing")},
+		id).Int()
+
+	return cancelled == 1, err
+}
+
+// Stats 各状态任务数
+func (q *DelayQueue) Stats(ctx context.Context) (DelayQueueStats, error) {
+	client, err := Get(ctx, q.Client)
+	if err != nil {
+		return DelayQueueStats{}, err
+	}
+
+	pipe := client.Pipeline()
+	delayed := pipe.ZCard(ctx, q.key("delayed"))
+	ready := pipe.LLen(ctx, q.key("ready"))
+	processing := pipe.ZCard(ctx, q.key("processing"))
+	dead := pipe.HLen(ctx, q.key("dead"))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return DelayQueueStats{}, err
+	}
+
+	return DelayQueueStats{
+		Delayed:    delayed.Val(),
+		Ready:      ready.Val(),
+		Processing: processing.Val(),
+		Dead:       dead.Val(),
+	}, nil
+}
+
+// Dead 超过最大执行次数的任务，任务ID => 内容
+func (q *DelayQueue) Dead(ctx context.Context) (map[string]string, error) {
+	client, err := Get(ctx, q.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.HGetAll(ctx, q.key("dead")).Result()
+}
+
+// Run 运行，阻塞直至ctx结束或收到 signal.Listen 监听的信号，等待处理中的任务完成后返回
+func (q *DelayQueue) Run(ctx context.Context) error {
+	if q.Name == "" || q.Handler == nil {
+		return errors.New("redis: delay queue name and handler required")
+	}
+
+	ctx, cancel := signal.Context(ctx)
+	defer cancel()
+
+	q.sem = make(chan struct{}, q.concurrency())
+	for ctx.Err() == nil {
+		// 有空闲名额时才领取，按空闲名额批量领取
+		if !q.acquire(ctx) {
+			break
+		}
+
+		jobs, err := q.reserve(ctx, 1+q.idle())
+		if err != nil || len(jobs) == 0 {
+			<-q.sem
+			if err != nil && ctx.Err() == nil {
+				logger.Error("延迟队列领取失败", q.Name, err.Error())
+			}
+			q.sleep(ctx, q.pollInterval())
+			continue
+		}
+
+		for index, job := range jobs {
+			if index > 0 && !q.acquire(ctx) {
+				// 按空闲名额领取，不会发生；已领取未处理的任务超时后重新投递
+				break
+			}
+			q.handle(ctx, job)
+		}
+	}
+
+	q.wait.Wait()
+	return nil
+}
+
+// reserve 转移到期任务并领取
+func (q *DelayQueue) reserve(ctx context.Context, count int) ([]DelayJob, error) {
+	client, err := Get(ctx, q.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	err = scriptDelayPromote.Run(ctx, client,
+		[]string{q.key("delayed"), q.key("ready"), q.key("processing")},
+		max(count, 100)).Err()
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := scriptDelayReserve.Run(ctx, client,
+		[]string{q.key("ready"), q.key("processing"), q.key("jobs"), q.key("attempts"), q.key("dead")},
+		count, q.visibility().Milliseconds(), q.maxAttempts()).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]DelayJob, 0, len(values)/3)
+	for i := 0; i+2 < len(values); i += 3 {
+		id, _ := values[i].(string)
+		payload, _ := values[i+1].(string)
+		attempts, _ := values[i+2].(int64)
+		jobs = append(jobs, DelayJob{ID: id, Payload: payload, Attempts: attempts})
+	}
+
+	return jobs, nil
+}
+
+// handle 异步处理，成功时完成，失败时延迟重试；调用前需已占用并发名额
+func (q *DelayQueue) handle(ctx context.Context, job DelayJob) {
+	q.wait.Add(1)
+	go func() {
+		defer q.wait.Done()
+		defer func() { <-q.sem }()
+
+		// 停止时等待处理完成，处理时长不超过 Visibility
+		handleCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), q.visibility())
+		defer cancel()
+
+		// 处理完成后再获取连接，连接失败按重试或完成失败处理，任务超时后重新投递
+		doneCtx := context.WithoutCancel(ctx)
+		if err := q.call(handleCtx, job); err != nil {
+			logger.Warn("延迟任务处理失败", q.Name, job.ID, job.Attempts, err.Error())
+
+			var result int
+			client, err := Get(doneCtx, q.Client)
+			if err == nil {
+				result, err = scriptDelayRetry.Run(doneCtx, client,
+					[]string{q.key("processing"), q.key("delayed"), q.key("jobs"), q.key("attempts"), q.key("dead")},
+					job.ID, job.Attempts, q.backoff(job.Attempts).Milliseconds(), q.maxAttempts()).Int()
+			}
+			if err != nil {
+				logger.Error("延迟任务重试失败", q.Name, job.ID, err.Error())
+			} else if result == 2 {
+				logger.Error("延迟任务超过最大执行次数", q.Name, job.ID, job.Attempts)
+			}
+			return
+		}
+
+		client, err := Get(doneCtx, q.Client)
+		if err == nil {
+			err = scriptDelayAck.Run(doneCtx, client,
+				[]string{q.key("processing"), q.key("jobs"), q.key("attempts")},
+				job.ID, job.Attempts).Err()
+		}
+		if err != nil {
+			logger.Error("延迟任务完成失败", q.Name, job.ID, err.Error())
+		}
+	}()
+}
+
+// call 调用处理函数，panic 视为处理失败
+func (q *DelayQueue) call(ctx context.Context, job DelayJob) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return q.Handler(ctx, job)
+}
+
+// key 同一队列的键使用相同的哈希标签，集群模式下位于同一槽位
+func (q *DelayQueue) key(name string) string {
+	return NamespaceKey(KeyDelayQueue + "{" + q.Name + "}:" + name)
+}
+
+// acquire 占用并发名额，ctx结束时返回 false；有空闲名额时优先占用，保证已领取的任务被处理
+func (q *DelayQueue) acquire(ctx context.Context) bool {
+	select {
+	case q.sem <- struct{}{}:
+		return true
+	default:
+	}
+
+	select {
+	case q.sem <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// idle 空闲名额
+func (q *DelayQueue) idle() int {
+	return cap(q.sem) - len(q.sem)
+}
+
+func (q *DelayQueue) sleep(ctx context.Context, duration time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(duration):
+	}
+}
+
+func (q *DelayQueue) concurrency() int {
+	if q.Concurrency <= 0 {
+		return 10
+	}
+
+	return q.Concurrency
+}
+
+func (q *DelayQueue) visibility() time.Duration {
+	if q.Visibility <= 0 {
+		return time.Minute
+	}
+
+	return q.Visibility
+}
+
+func (q *DelayQueue) maxAttempts() int64 {
+	if q.MaxAttempts <= 0 {
+		return 5
+	}
+
+	return q.MaxAttempts
+}
+
+func (q *DelayQueue) backoff(attempts int64) time.Duration {
+	if q.Backoff != nil {
+		return q.Backoff(attempts)
+	}
+
+	return min(time.Second<<min(max(attempts-1, 0), 12), time.Hour)
+}
+
+func (q *DelayQueue) pollInterval() time.Duration {
+	if q.PollInterval <= 0 {
+		return time.Second
+	}
+
+	return q.PollInterval
+}
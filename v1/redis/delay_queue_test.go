@@ -0,0 +1,293 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// runQueue 后台运行，返回停止函数，停止时等待 Run 返回
+func runQueue(t *testing.T, queue *DelayQueue) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- queue.Run(ctx)
+	}()
+
+	return func() {
+		cancel()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Run 返回错误: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run 未停止")
+		}
+	}
+}
+
+// TestDelayQueue 测试到期执行与去重
+func TestDelayQueue(t *testing.T) {
+	setupRedis(t, "delay_ok")
+	ctx := context.Background()
+
+	var mutex sync.Mutex
+	executed := map[string]time.Time{}
+	queue := NewDelayQueue("orders", func(ctx context.Context, job DelayJob) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if job.Attempts != 1 {
+			t.Errorf("期望第1次执行，实际第%d次", job.Attempts)
+		}
+		executed[job.ID+":"+job.Payload] = time.Now()
+		return nil
+	})
+	queue.Client = "delay_ok"
+	queue.PollInterval = 10 * time.Millisecond
+
+	start := time.Now()
+	if added, err := queue.Add(ctx, "1", "cancel", 200*time.Millisecond); !added || err != nil {
+		t.Fatalf("添加失败 %v %v", added, err)
+	}
+	if added, _ := queue.Add(ctx, "1", "duplicate", 0); added {
+		t.Error("重复的任务ID不应该添加成功")
+	}
+	queue.AddAt(ctx, "2", "now", time.Now())
+
+	stop := runQueue(t, queue)
+	waitUntil(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(executed) == 2
+	}, "任务未全部执行")
+	stop()
+
+	if executed["1:cancel"].Sub(start) < 200*time.Millisecond {
+		t.Error("任务提前执行")
+	}
+	if !executed["2:now"].Before(executed["1:cancel"]) {
+		t.Error("先到期的任务应该先执行")
+	}
+
+	stats, _ := queue.Stats(ctx)
+	if stats != (DelayQueueStats{}) {
+		t.Errorf("期望队列为空，实际为 %+v", stats)
+	}
+	// 完成后可以再次添加
+	if added, _ := queue.Add(ctx, "1", "again", time.Hour); !added {
+		t.Error("完成后应该可以再次添加")
+	}
+}
+
+// TestDelayQueueRetry 测试失败重试与超过最大执行次数
+func TestDelayQueueRetry(t *testing.T) {
+	setupRedis(t, "delay_retry")
+	ctx := context.Background()
+
+	var mutex sync.Mutex
+	attempts := map[string][]int64{}
+	queue := NewDelayQueue("retry", func(ctx context.Context, job DelayJob) error {
+		mutex.Lock()
+		attempts[job.ID] = append(attempts[job.ID], job.Attempts)
+		mutex.Unlock()
+
+		if job.ID == "flaky" && job.Attempts < 3 {
+			return errors.New("temporary")
+		}
+		if job.ID == "broken" {
+			panic("always fail")
+		}
+		return nil
+	})
+	queue.Client = "delay_retry"
+	queue.PollInterval = 10 * time.Millisecond
+	queue.MaxAttempts = 3
+	queue.Backoff = func(attempts int64) time.Duration {
+		return time.Duration(attempts) * 20 * time.Millisecond
+	}
+
+	queue.Add(ctx, "flaky", "a", 0)
+	queue.Add(ctx, "broken", "b", 0)
+
+	stop := runQueue(t, queue)
+	waitUntil(t, func() bool {
+		stats, _ := queue.Stats(ctx)
+		return stats.Dead == 1 && stats.Delayed == 0 && stats.Ready == 0 && stats.Processing == 0
+	}, "任务未处理完成")
+	stop()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(attempts["flaky"]) != 3 || attempts["flaky"][2] != 3 {
+		t.Errorf("期望第3次成功，实际执行 %v", attempts["flaky"])
+	}
+	if len(attempts["broken"]) != 3 {
+		t.Errorf("期望执行3次，实际执行 %v", attempts["broken"])
+	}
+
+	dead, _ := queue.Dead(ctx)
+	if len(dead) != 1 || dead["broken"] != "b" {
+		t.Errorf("死信任务错误 %v", dead)
+	}
+}
+
+// TestDelayQueueVisibility 测试处理超时后重新投递，旧的执行结果被忽略
+func TestDelayQueueVisibility(t *testing.T) {
+	setupRedis(t, "delay_visibility")
+	ctx := context.Background()
+
+	var calls atomic.Int64
+	queue := NewDelayQueue("visibility", func(ctx context.Context, job DelayJob) error {
+		calls.Add(1)
+		if job.Attempts == 1 {
+			// 模拟卡住，超过处理超时
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		}
+		return nil
+	})
+	queue.Client = "delay_visibility"
+	queue.PollInterval = 10 * time.Millisecond
+	queue.Visibility = 100 * time.Millisecond
+
+	queue.Add(ctx, "slow", "", 0)
+
+	stop := runQueue(t, queue)
+	waitUntil(t, func() bool { return calls.Load() == 2 }, "超时任务未重新投递")
+	time.Sleep(100 * time.Millisecond)
+	stop()
+
+	stats, _ := queue.Stats(ctx)
+	if stats != (DelayQueueStats{}) {
+		t.Errorf("期望队列为空，实际为 %+v", stats)
+	}
+}
+
+// TestDelayQueueCancel 测试取消
+func TestDelayQueueCancel(t *testing.T) {
+	setupRedis(t, "delay_cancel")
+	ctx := context.Background()
+
+	var calls atomic.Int64
+	queue := NewDelayQueue("cancel", func(ctx context.Context, job DelayJob) error {
+		calls.Add(1)
+		return nil
+	})
+	queue.Client = "delay_cancel"
+	queue.PollInterval = 10 * time.Millisecond
+
+	queue.Add(ctx, "order", "", 100*time.Millisecond)
+	if cancelled, err := queue.Cancel(ctx, "order"); !cancelled || err != nil {
+		t.Fatalf("取消失败 %v %v", cancelled, err)
+	}
+	if cancelled, _ := queue.Cancel(ctx, "order"); cancelled {
+		t.Error("重复取消应该返回 false")
+	}
+
+	stop := runQueue(t, queue)
+	time.Sleep(200 * time.Millisecond)
+	stop()
+
+	if calls.Load() != 0 {
+		t.Error("已取消的任务不应该执行")
+	}
+	stats, _ := queue.Stats(ctx)
+	if stats != (DelayQueueStats{}) {
+		t.Errorf("期望队列为空，实际为 %+v", stats)
+	}
+}
+
+// TestDelayQueueConcurrency 测试并发数与停止时等待处理完成
+func TestDelayQueueConcurrency(t *testing.T) {
+	setupRedis(t, "delay_concurrency")
+	ctx := context.Background()
+
+	var handled, running, maxRunning atomic.Int32
+	queue := NewDelayQueue("concurrency", func(ctx context.Context, job DelayJob) error {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := maxRunning.Load()
+			if current <= old || maxRunning.CompareAndSwap(old, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		handled.Add(1)
+		return nil
+	})
+	queue.Client = "delay_concurrency"
+	queue.PollInterval = 10 * time.Millisecond
+	queue.Concurrency = 3
+
+	for i := 0; i < 10; i++ {
+		queue.Add(ctx, string(rune('a'+i)), "", 0)
+	}
+
+	stop := runQueue(t, queue)
+	waitUntil(t, func() bool { return handled.Load() >= 1 }, "任务未执行")
+	stop()
+
+	if running.Load() != 0 {
+		t.Error("Run 应该等待处理中的任务完成")
+	}
+	if maxRunning.Load() > 3 {
+		t.Errorf("并发数超过3，实际为%d", maxRunning.Load())
+	}
+
+	stats, _ := queue.Stats(ctx)
+	if stats.Processing != 0 || stats.Delayed+stats.Ready != int64(10-handled.Load()) {
+		t.Errorf("任务数错误 %+v，已处理%d", stats, handled.Load())
+	}
+}
+
+// TestDelayQueueRedisDown 测试 Redis 不可用时返回错误，领取与完成按失败处理，不panic
+func TestDelayQueueRedisDown(t *testing.T) {
+	s := setupRedis(t, "delay_down")
+	ctx := context.Background()
+
+	var calls atomic.Int64
+	queue := NewDelayQueue("down", func(ctx context.Context, job DelayJob) error {
+		// 处理中 Redis 不可用，完成时重新获取连接失败
+		s.Close()
+		Close("delay_down")
+		calls.Add(1)
+		return nil
+	})
+	queue.Client = "delay_down"
+	queue.PollInterval = 10 * time.Millisecond
+	queue.Add(ctx, "order", "", 0)
+
+	stop := runQueue(t, queue)
+	waitUntil(t, func() bool { return calls.Load() == 1 }, "任务未执行")
+	time.Sleep(50 * time.Millisecond)
+	stop()
+
+	if _, err := queue.Add(ctx, "order2", "", 0); err == nil {
+		t.Error("Redis 不可用时 Add 应该返回错误")
+	}
+	if _, err := queue.Cancel(ctx, "order"); err == nil {
+		t.Error("Redis 不可用时 Cancel 应该返回错误")
+	}
+	if _, err := queue.Stats(ctx); err == nil {
+		t.Error("Redis 不可用时 Stats 应该返回错误")
+	}
+	if _, err := queue.Dead(ctx); err == nil {
+		t.Error("Redis 不可用时 Dead 应该返回错误")
+	}
+}
+
+// TestDelayQueueInvalid 测试缺少配置
+func TestDelayQueueInvalid(t *testing.T) {
+	if err := NewDelayQueue("", nil).Run(context.Background()); err == nil {
+		t.Error("期望返回错误")
+	}
+	if _, err := NewDelayQueue("invalid", nil).Add(context.Background(), "", "", 0); err == nil {
+		t.Error("期望返回错误")
+	}
+}
@@ -0,0 +1,262 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lynnclub/go/v1/logger"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LatencyBuckets 耗时分布的区间上限，超过最后一个的计入最后一个区间之后，修改需在执行命令之前
+var LatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+var metrics = &sync.Map{} //指标，按配置名，Reload 后保留
+
+// CommandMetrics 命令指标
+type CommandMetrics struct {
+	Count   int64         `json:"count"`   //执行次数
+	Errors  int64         `json:"errors"`  //错误次数，不含 redis.Nil
+	Slow    int64         `json:"slow"`    //慢命令次数
+	Total   time.Duration `json:"total"`   //总耗时
+	Buckets []int64       `json:"buckets"` //耗时分布，与 LatencyBuckets 对应，多出的最后一个为超过全部区间的次数
+}
+
+type commandMetrics struct {
+	count   atomic.Int64
+	errors  atomic.Int64
+	slow    atomic.Int64
+	total   atomic.Int64
+	buckets []atomic.Int64
+}
+
+// Metrics 各命令的指标，管道记为 pipeline，可定期导出到监控系统
+func Metrics(name string) map[string]CommandMetrics {
+	if name == "" {
+		name = "default"
+	}
+
+	result := make(map[string]CommandMetrics)
+	commands, ok := metrics.Load(name)
+	if !ok {
+		return result
+	}
+
+	commands.(*sync.Map).Range(func(command, value any) bool {
+		current := value.(*commandMetrics)
+		snapshot := CommandMetrics{
+			Count:   current.count.Load(),
+			Errors:  current.errors.Load(),
+			Slow:    current.slow.Load(),
+			Total:   time.Duration(current.total.Load()),
+			Buckets: make([]int64, len(current.buckets)),
+		}
+		for index := range current.buckets {
+			snapshot.Buckets[index] = current.buckets[index].Load()
+		}
+		result[command.(string)] = snapshot
+		return true
+	})
+
+	return result
+}
+
+// Hook 命令钩子，记录慢命令日志、各命令的耗时分布与错误次数，开启链路追踪时输出 Span
+// 添加配置时默认安装，DisableHook 关闭
+type Hook struct {
+	Name          string        //配置名
+	SlowThreshold time.Duration //慢命令阈值，超过时通过 v1/logger 记录
+	Trace         bool          //输出 OpenTelemetry Span，使用全局 TracerProvider
+}
+
+// NewHook 实例化
+func NewHook(name string, option Option) *Hook {
+	return &Hook{
+		Name:          name,
+		SlowThreshold: option.SlowThreshold,
+		Trace:         option.Trace,
+	}
+}
+
+// DialHook 不处理
+func (h *Hook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook 单个命令
+func (h *Hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := h.start(ctx, cmd.FullName(), 1)
+
+		start := time.Now()
+		err := next(ctx, cmd)
+		elapsed := time.Since(start)
+
+		// 命令错误在钩子返回后才写入 cmd
+		var failed error
+		if isFailed(err) {
+			failed = err
+		}
+		h.finish(span, cmd.FullName(), elapsed, failed)
+		if h.isSlow(elapsed) {
+			logger.Warn("redis 慢命令", h.Name, cmd.FullName(), key(cmd), elapsed.String(), traceID(ctx))
+		}
+
+		return err
+	}
+}
+
+// ProcessPipelineHook 管道与事务，整体记为一次 pipeline
+func (h *Hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := h.start(ctx, "pipeline", len(cmds))
+
+		start := time.Now()
+		err := next(ctx, cmds)
+		elapsed := time.Since(start)
+
+		var failed error
+		for _, cmd := range cmds {
+			if isFailed(cmd.Err()) {
+				failed = cmd.Err()
+				break
+			}
+		}
+		if failed == nil && isFailed(err) {
+			failed = err
+		}
+
+		h.finish(span, "pipeline", elapsed, failed)
+		if h.isSlow(elapsed) {
+			names := make([]string, 0, len(cmds))
+			for _, cmd := range cmds {
+				names = append(names, cmd.FullName())
+			}
+			logger.Warn("redis 慢命令", h.Name, "pipeline", strings.Join(names, ","), elapsed.String(), traceID(ctx))
+		}
+
+		return err
+	}
+}
+
+// start 开启链路追踪时创建 Span
+func (h *Hook) start(ctx context.Context, command string, size int) (context.Context, trace.Span) {
+	if !h.Trace {
+		return ctx, nil
+	}
+
+	return otel.Tracer("github.com/lynnclub/go/v1/redis").Start(ctx, "redis "+command,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", command),
+			attribute.String("db.redis.name", h.Name),
+			attribute.Int("db.redis.num_cmd", size),
+		),
+	)
+}
+
+// finish 记录指标并结束 Span，failed 为 nil 表示成功
+func (h *Hook) finish(span trace.Span, command string, elapsed time.Duration, failed error) {
+	current := h.metrics(command)
+	current.count.Add(1)
+	current.total.Add(int64(elapsed))
+	bucket := sort.Search(len(LatencyBuckets), func(i int) bool {
+		return elapsed <= LatencyBuckets[i]
+	})
+	current.buckets[min(bucket, len(current.buckets)-1)].Add(1)
+	if failed != nil {
+		current.errors.Add(1)
+	}
+	if h.isSlow(elapsed) {
+		current.slow.Add(1)
+	}
+
+	if span == nil {
+		return
+	}
+	if failed != nil {
+		span.RecordError(failed)
+		span.SetStatus(codes.Error, failed.Error())
+	}
+	span.End()
+}
+
+// isSlow 是否慢命令，阈值不大于0时不记录
+func (h *Hook) isSlow(elapsed time.Duration) bool {
+	return h.SlowThreshold > 0 && elapsed >= h.SlowThreshold
+}
+
+// metrics 获取或创建命令指标
+func (h *Hook) metrics(command string) *commandMetrics {
+	commands, ok := metrics.Load(h.Name)
+	if !ok {
+		commands, _ = metrics.LoadOrStore(h.Name, &sync.Map{})
+	}
+	if current, ok := commands.(*sync.Map).Load(command); ok {
+		return current.(*commandMetrics)
+	}
+
+	current, _ := commands.(*sync.Map).LoadOrStore(command, &commandMetrics{
+		buckets: make([]atomic.Int64, len(LatencyBuckets)+1),
+	})
+	return current.(*commandMetrics)
+}
+
+// isFailed redis.Nil 不是错误
+func isFailed(err error) bool {
+	return err != nil && !errors.Is(err, Nil)
+}
+
+// key 命令的第一个键名，不记录值避免泄露数据
+// 脚本命令取第一个 KEYS，numkeys 为0时 args[3] 是 ARGV，不记录
+func key(cmd redis.Cmder) string {
+	args := cmd.Args()
+	index := 1
+	switch cmd.Name() {
+	case "eval", "evalsha", "eval_ro", "evalsha_ro", "fcall", "fcall_ro":
+		if len(args) < 3 {
+			return ""
+		}
+		if numKeys, err := strconv.Atoi(fmt.Sprint(args[2])); err != nil || numKeys <= 0 {
+			return ""
+		}
+		index = 3
+	}
+
+	if len(args) > index {
+		if key, ok := args[index].(string); ok {
+			return key
+		}
+	}
+
+	return ""
+}
+
+// traceID 上下文中的链路标识，没有时为空
+func traceID(ctx context.Context) string {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
+
+	return ""
+}
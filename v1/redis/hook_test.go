@@ -0,0 +1,165 @@
+package redis
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lynnclub/go/v1/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordProvider 记录 Span
+type recordProvider struct {
+	embedded.TracerProvider
+
+	mutex sync.Mutex
+	spans []*recordSpan
+}
+
+func (p *recordProvider) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
+	return &recordTracer{provider: p}
+}
+
+type recordTracer struct {
+	embedded.Tracer
+
+	provider *recordProvider
+}
+
+func (tracer *recordTracer) Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &recordSpan{name: name, context: trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0xab},
+		SpanID:  trace.SpanID{0x01},
+	})}
+
+	tracer.provider.mutex.Lock()
+	defer tracer.provider.mutex.Unlock()
+	tracer.provider.spans = append(tracer.provider.spans, span)
+
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type recordSpan struct {
+	noop.Span
+
+	name    string
+	context trace.SpanContext
+	status  codes.Code
+	ended   bool
+}
+
+func (s *recordSpan) SpanContext() trace.SpanContext                { return s.context }
+func (s *recordSpan) SetStatus(code codes.Code, description string) { s.status = code }
+func (s *recordSpan) End(options ...trace.SpanEndOption)            { s.ended = true }
+
+// TestHookMetrics 测试各命令的次数、错误数与耗时分布
+func TestHookMetrics(t *testing.T) {
+	s := setupMiniRedis(t)
+	ctx := context.Background()
+	Add("test_hook_metrics", Option{Address: []string{s.Addr()}, SlowThreshold: -1})
+	t.Cleanup(func() { Close("test_hook_metrics") })
+	client := Universal("test_hook_metrics")
+
+	client.Set(ctx, "name", "value", 0)
+	client.Get(ctx, "name")
+	client.Get(ctx, "missing")
+	client.Incr(ctx, "name")
+	pipe := client.Pipeline()
+	pipe.Set(ctx, "a", "1", 0)
+	pipe.Incr(ctx, "name")
+	pipe.Exec(ctx)
+
+	metrics := Metrics("test_hook_metrics")
+	if get := metrics["get"]; get.Count != 2 || get.Errors != 0 {
+		t.Errorf("redis.Nil 不应该计为错误 %+v", get)
+	}
+	if incr := metrics["incr"]; incr.Count != 1 || incr.Errors != 1 {
+		t.Errorf("期望错误1次 %+v", incr)
+	}
+	if pipeline := metrics["pipeline"]; pipeline.Count != 1 || pipeline.Errors != 1 {
+		t.Errorf("期望管道记为1次且失败 %+v", pipeline)
+	}
+
+	set := metrics["set"]
+	var total int64
+	for _, count := range set.Buckets {
+		total += count
+	}
+	if len(set.Buckets) != len(LatencyBuckets)+1 || total != set.Count || set.Total <= 0 || set.Slow != 0 {
+		t.Errorf("耗时分布错误 %+v", set)
+	}
+
+	// 关闭钩子
+	Add("test_hook_disabled", Option{Address: []string{s.Addr()}, DisableHook: true})
+	t.Cleanup(func() { Close("test_hook_disabled") })
+	Universal("test_hook_disabled").Get(ctx, "name")
+	if len(Metrics("test_hook_disabled")) != 0 {
+		t.Error("关闭钩子后不应该记录")
+	}
+}
+
+// TestHookSlow 测试慢命令日志与链路追踪
+func TestHookSlow(t *testing.T) {
+	s := setupMiniRedis(t)
+	ctx := context.Background()
+
+	var output bytes.Buffer
+	writer := logger.Logger.Raw.Writer()
+	logger.Logger.Raw.SetOutput(&output)
+	provider := &recordProvider{}
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		logger.Logger.Raw.SetOutput(writer)
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		Close("test_hook_slow")
+	})
+
+	AddMap("test_hook_slow", map[string]interface{}{
+		"address":        []interface{}{s.Addr()},
+		"slow_threshold": "1ns",
+		"trace":          true,
+	})
+	client := Universal("test_hook_slow")
+	client.Set(ctx, "user:1", "secret", time.Minute)
+	client.Incr(ctx, "user:1")
+	client.Eval(ctx, "return redis.call('GET', KEYS[1])", []string{"script:1"})
+	client.Eval(ctx, "return ARGV[1]", nil, "argv:token")
+
+	log := output.String()
+	if !strings.Contains(log, "redis 慢命令") || !strings.Contains(log, "user:1") {
+		t.Errorf("期望记录慢命令 %s", log)
+	}
+	if strings.Contains(log, "secret") || strings.Contains(log, "redis.call") || strings.Contains(log, "argv:token") {
+		t.Error("不应该记录值、脚本与 numkeys 为0时的参数")
+	}
+	if !strings.Contains(log, "script:1") {
+		t.Errorf("脚本命令期望记录第一个键名 %s", log)
+	}
+	if !strings.Contains(log, trace.TraceID{0xab}.String()) {
+		t.Errorf("期望记录链路标识 %s", log)
+	}
+	if Metrics("test_hook_slow")["set"].Slow != 1 {
+		t.Error("期望慢命令计数")
+	}
+
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+	spans := map[string]*recordSpan{}
+	for _, span := range provider.spans {
+		spans[span.name] = span
+	}
+	if span, ok := spans["redis set"]; !ok || !span.ended || span.status == codes.Error {
+		t.Errorf("期望输出成功的 Span %+v", span)
+	}
+	if span, ok := spans["redis incr"]; !ok || span.status != codes.Error {
+		t.Errorf("期望输出失败的 Span %+v", span)
+	}
+}
@@ -0,0 +1,263 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lynnclub/go/v1/signal"
+	"github.com/redis/go-redis/v9"
+	"github.com/robfig/cron/v3"
+)
+
+var ErrNotLeader = errors.New("redis: not leader")
+
+// 竞选或续期，KEYS[1] 租约，KEYS[2] 令牌计数器
+// ARGV[1] 候选者标识，ARGV[2] 租期（毫秒）
+// 已是领导者时续期并返回原令牌，租约空闲时当选并返回递增的新令牌，否则返回0
+var scriptElect = redis.NewScript(`
+local id = redis.call("HGET", KEYS[1], "id")
+if id == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return tonumber(redis.call("HGET", KEYS[1], "token"))
+end
+if id then
+	return 0
+end
+local token = redis.call("INCR", KEYS[2])
+redis.call("HSET", KEYS[1], "id", ARGV[1], "token", token)
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+return token`)
+
+// 卸任，仅删除自己的租约，ARGV[1] 候选者标识
+var scriptResign = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "id") == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Leader 领导者选举，同名实例中只有一个领导者，用于多实例部署时只需单实例执行的任务
+// 领导者持有可续期的租约，崩溃后租约过期由其他实例接任
+// 每次当选获得单调递增的防护令牌（fencing token），下游写入时校验令牌，可拒绝已失去领导权的旧领导者
+type Leader struct {
+	Name          string                                 // 选举名称
+	Client        string                                 // redis配置名称，留空使用default
+	ID            string                                 // 候选者标识，默认 主机名-进程号-随机串
+	Lease         time.Duration                          // 租期，默认15秒，领导者崩溃后最长经过该时间重新选举
+	RenewInterval time.Duration                          // 续期与竞选间隔，默认 Lease/3
+	OnElected     func(ctx context.Context, token int64) // 当选时在独立协程中调用，ctx 在失去领导权或停止时结束，可长时间运行
+	OnLost        func(token int64)                      // 失去领导权时调用，包括被他人接任、续期超时与停止
+
+	token   int64
+	renewed time.Time
+	cancel  context.CancelFunc
+	ctx     context.Context
+	mutex   sync.RWMutex
+	wait    sync.WaitGroup // 执行中的 OnElected
+}
+
+// NewLeader 领导者选举实例化
+func NewLeader(name string) *Leader {
+	return &Leader{Name: name}
+}
+
+// Campaign 参与选举，阻塞直至ctx结束或收到 signal.Listen 监听的信号，返回前卸任并等待 OnElected 返回
+// 续期出错时，距上次成功续期超过 Lease-RenewInterval 即视为失去领导权，避免租约过期后仍自认为领导者
+func (l *Leader) Campaign(ctx context.Context) error {
+	if l.Name == "" {
+		return errors.New("redis: leader name required")
+	}
+
+	ctx, cancel := signal.Context(ctx)
+	defer cancel()
+
+	if l.ID == "" {
+		hostname, _ := os.Hostname()
+		l.ID = fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), newToken()[:8])
+	}
+
+	ticker := time.NewTicker(l.renewInterval())
+	defer ticker.Stop()
+
+	for {
+		l.elect(ctx)
+
+		select {
+		case <-ctx.Done():
+			l.resign()
+			l.wait.Wait()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsLeader 是否领导者
+func (l *Leader) IsLeader() bool {
+	return l.Token() != 0
+}
+
+// Token 本次任期的防护令牌，非领导者时为0
+func (l *Leader) Token() int64 {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	return l.token
+}
+
+// Check 向 redis 确认 token 仍为当前领导者的令牌，否则返回 ErrNotLeader，可在提交结果前调用
+func (l *Leader) Check(ctx context.Context, token int64) error {
+	current, err := Universal(l.Client).HGet(ctx, l.key(), "token").Int64()
+	if err != nil && !errors.Is(err, Nil) {
+		return err
+	}
+	if token == 0 || current != token {
+		return ErrNotLeader
+	}
+
+	return nil
+}
+
+// Run 仅在领导者上执行 job，非领导者时返回 ErrNotLeader
+// job 的 ctx 在失去领导权时结束，token 为本次任期的防护令牌
+func (l *Leader) Run(job func(ctx context.Context, token int64) error) error {
+	l.mutex.RLock()
+	token, ctx := l.token, l.ctx
+	l.mutex.RUnlock()
+
+	if token == 0 {
+		return ErrNotLeader
+	}
+
+	return job(ctx, token)
+}
+
+// Job 转为 cron 任务，仅在领导者上执行，如 scheduler.AddJob("@every 1m", leader.Job(job))，job 的错误需自行处理
+func (l *Leader) Job(job func(ctx context.Context, token int64) error) cron.Job {
+	return cron.FuncJob(func() {
+		_ = l.Run(job)
+	})
+}
+
+// Wrapper cron 任务包装，如 cron.New(cron.WithChain(leader.Wrapper()))，调度器中的所有任务仅在领导者上执行
+func (l *Leader) Wrapper() cron.JobWrapper {
+	return func(job cron.Job) cron.Job {
+		return cron.FuncJob(func() {
+			if l.IsLeader() {
+				job.Run()
+			}
+		})
+	}
+}
+
+// elect 竞选或续期，并处理领导权变化
+func (l *Leader) elect(ctx context.Context) {
+	// 租约从发出请求时起算，与 Redlock 计算有效期一致，避免把网络耗时计入租约
+	start := time.Now()
+	timeoutCtx, cancel := context.WithTimeout(ctx, l.renewInterval())
+	var token int64
+	client, err := Get(timeoutCtx, l.Client)
+	if err == nil {
+		token, err = scriptElect.Run(timeoutCtx, client, l.keys(), l.ID, l.lease().Milliseconds()).Int64()
+	}
+	cancel()
+
+	var lost, elected int64
+	var electedCtx context.Context
+	l.mutex.Lock()
+	switch {
+	case err != nil:
+		// 无法确认时保留领导权，直至租约即将过期
+		if l.token != 0 && time.Since(l.renewed) >= l.lease()-l.renewInterval() {
+			lost = l.token
+			l.stepDown()
+		}
+	case token == l.token:
+		l.renewed = start
+	default:
+		if l.token != 0 {
+			lost = l.token
+			l.stepDown()
+		}
+		if token != 0 {
+			l.token, l.renewed = token, start
+			l.ctx, l.cancel = context.WithCancel(context.Background())
+			elected, electedCtx = token, l.ctx
+		}
+	}
+	l.mutex.Unlock()
+
+	// 回调在锁外执行，回调中可调用 IsLeader、Token
+	if lost != 0 && l.OnLost != nil {
+		l.OnLost(lost)
+	}
+	// 在独立协程中执行，不阻塞续期
+	if elected != 0 && l.OnElected != nil {
+		l.wait.Add(1)
+		go func() {
+			defer l.wait.Done()
+			l.OnElected(electedCtx, elected)
+		}()
+	}
+}
+
+// resign 停止时卸任，其他实例无需等待租约过期
+func (l *Leader) resign() {
+	l.mutex.Lock()
+	token := l.token
+	if token != 0 {
+		l.stepDown()
+	}
+	l.mutex.Unlock()
+
+	if token == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.renewInterval())
+	defer cancel()
+	if client, err := Get(ctx, l.Client); err == nil {
+		_ = scriptResign.Run(ctx, client, []string{l.key()}, l.ID).Err()
+	}
+
+	if l.OnLost != nil {
+		l.OnLost(token)
+	}
+}
+
+// stepDown 清除本地领导权并结束任期上下文，需在 l.mutex 内调用
+func (l *Leader) stepDown() {
+	l.token = 0
+	if l.cancel != nil {
+		l.cancel()
+		l.cancel = nil
+	}
+}
+
+func (l *Leader) key() string {
+	return NamespaceKey(KeyBase + "leader:{" + l.Name + "}")
+}
+
+// keys 租约与令牌计数器，使用哈希标签位于同一槽位
+func (l *Leader) keys() []string {
+	return []string{l.key(), l.key() + ":fencing"}
+}
+
+func (l *Leader) lease() time.Duration {
+	if l.Lease <= 0 {
+		return 15 * time.Second
+	}
+
+	return l.Lease
+}
+
+func (l *Leader) renewInterval() time.Duration {
+	if l.RenewInterval <= 0 {
+		return l.lease() / 3
+	}
+
+	return l.RenewInterval
+}
@@ -0,0 +1,210 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/robfig/cron/v3"
+)
+
+// TestLeader 测试只有一个领导者、卸任后接任与防护令牌递增
+func TestLeader(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("test_leader", Option{Address: []string{s.Addr()}})
+	t.Cleanup(func() { Close("test_leader") })
+
+	var events sync.Map
+	newLeader := func(id string) *Leader {
+		leader := NewLeader("job")
+		leader.Client = "test_leader"
+		leader.ID = id
+		leader.RenewInterval = 10 * time.Millisecond
+		leader.OnElected = func(ctx context.Context, token int64) {
+			events.Store(id+":elected", token)
+		}
+		leader.OnLost = func(token int64) {
+			events.Store(id+":lost", token)
+		}
+		return leader
+	}
+
+	a, b := newLeader("a"), newLeader("b")
+	ctxA, cancelA := context.WithCancel(context.Background())
+	doneA := make(chan error)
+	go func() { doneA <- a.Campaign(ctxA) }()
+	waitUntil(t, a.IsLeader, "a 未当选")
+
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	go func() { _ = b.Campaign(ctxB) }()
+	time.Sleep(50 * time.Millisecond)
+	if b.IsLeader() {
+		t.Fatal("只能有一个领导者")
+	}
+
+	ctx := context.Background()
+	first := a.Token()
+	if err := a.Check(ctx, first); err != nil {
+		t.Errorf("令牌应该有效: %v", err)
+	}
+	if err := b.Run(func(ctx context.Context, token int64) error { return nil }); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("非领导者应该返回 ErrNotLeader: %v", err)
+	}
+
+	// 停止后卸任，b 接任且令牌递增
+	cancelA()
+	if err := <-doneA; err != nil {
+		t.Errorf("Campaign 返回错误: %v", err)
+	}
+	if a.IsLeader() {
+		t.Error("停止后不应该是领导者")
+	}
+	waitUntil(t, b.IsLeader, "b 未接任")
+	second := b.Token()
+	if second <= first {
+		t.Errorf("令牌应该递增 %d %d", first, second)
+	}
+	if err := b.Check(ctx, first); !errors.Is(err, ErrNotLeader) {
+		t.Error("旧令牌应该失效")
+	}
+	if token, _ := events.Load("a:lost"); token != first {
+		t.Errorf("期望 a 失去领导权回调 %v", token)
+	}
+	waitUntil(t, func() bool {
+		token, _ := events.Load("b:elected")
+		return token == second
+	}, "期望 b 当选回调")
+
+	// 租约被他人占有时失去领导权，任期上下文结束
+	var jobCtx context.Context
+	_ = b.Run(func(ctx context.Context, token int64) error {
+		jobCtx = ctx
+		return nil
+	})
+	s.Del(b.key())
+	s.HSet(b.key(), "id", "other", "token", "100")
+	waitUntil(t, func() bool { return !b.IsLeader() }, "b 未失去领导权")
+	if jobCtx.Err() == nil {
+		t.Error("失去领导权时任期上下文应该结束")
+	}
+}
+
+// TestLeaderCron 测试 cron 任务仅在领导者上执行
+func TestLeaderCron(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("test_leader_cron", Option{Address: []string{s.Addr()}})
+	t.Cleanup(func() { Close("test_leader_cron") })
+
+	leader := NewLeader("cron")
+	leader.Client = "test_leader_cron"
+	leader.RenewInterval = 10 * time.Millisecond
+
+	var calls atomic.Int64
+	job := leader.Job(func(ctx context.Context, token int64) error {
+		calls.Add(1)
+		return nil
+	})
+	wrapped := cron.NewChain(leader.Wrapper()).Then(cron.FuncJob(func() {
+		calls.Add(1)
+	}))
+
+	job.Run()
+	wrapped.Run()
+	if calls.Load() != 0 {
+		t.Error("非领导者不应该执行")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = leader.Campaign(ctx) }()
+	waitUntil(t, leader.IsLeader, "未当选")
+
+	job.Run()
+	wrapped.Run()
+	if calls.Load() != 2 {
+		t.Errorf("领导者应该执行，实际%d次", calls.Load())
+	}
+}
+
+// TestLeaderOnElectedAsync 测试长时间运行的 OnElected 不阻塞续期，停止时 ctx 结束且 Campaign 等待其返回
+func TestLeaderOnElectedAsync(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("test_leader_async", Option{Address: []string{s.Addr()}})
+	t.Cleanup(func() { Close("test_leader_async") })
+
+	var started, finished atomic.Bool
+	leader := NewLeader("async")
+	leader.Client = "test_leader_async"
+	leader.Lease = 300 * time.Millisecond
+	leader.RenewInterval = 10 * time.Millisecond
+	leader.OnElected = func(ctx context.Context, token int64) {
+		started.Store(true)
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		finished.Store(true)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- leader.Campaign(ctx) }()
+	waitUntil(t, started.Load, "OnElected 未执行")
+
+	// 回调阻塞期间持续续期
+	s.FastForward(200 * time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	if !leader.IsLeader() || s.TTL(leader.key()) <= 200*time.Millisecond {
+		t.Errorf("OnElected 不应该阻塞续期，剩余租期 %v", s.TTL(leader.key()))
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Campaign 返回错误: %v", err)
+	}
+	if !finished.Load() {
+		t.Error("Campaign 应该等待 OnElected 返回")
+	}
+}
+
+// slowHook 命令延迟执行，模拟网络耗时
+type slowHook time.Duration
+
+func (h slowHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h slowHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		time.Sleep(time.Duration(h))
+		return next(ctx, cmd)
+	}
+}
+
+func (h slowHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// TestLeaderRenewedAtStart 测试续期时间从发出请求时起算，不计入网络耗时
+func TestLeaderRenewedAtStart(t *testing.T) {
+	setupRedis(t, "test_leader_slow")
+	Use("test_leader_slow").AddHook(slowHook(50 * time.Millisecond))
+
+	leader := NewLeader("slow")
+	leader.Client = "test_leader_slow"
+
+	start := time.Now()
+	leader.elect(context.Background())
+	if !leader.IsLeader() {
+		t.Fatal("应该当选")
+	}
+	if elapsed := leader.renewed.Sub(start); elapsed >= 50*time.Millisecond {
+		t.Errorf("续期时间应该为发出请求时，实际晚了 %v", elapsed)
+	}
+}
@@ -1,13 +1,54 @@
 package redis
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mathRand "math/rand"
+	"sync"
 	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrLockFailed  = errors.New("redis: lock not acquired")
+	ErrLockNotHeld = errors.New("redis: lock not held")
+	ErrLockOwner   = errors.New("redis: lock owner empty")
+)
+
+var (
+	// 持有者一致才删除
+	scriptUnlock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+	// 持有者一致才续期
+	scriptRefresh = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
 )
 
 // Lock 加锁，单位秒
+//
+// Deprecated: 不校验持有者，过期后可能释放其他进程的锁，请使用 Mutex
 func Lock(name string, expire time.Duration) bool {
-	result, err := Use("").
-		SetNX(Ctx, KeyLock+name, 1, expire*time.Second).
+	return LockDuration(name, expire*time.Second)
+}
+
+// LockDuration 加锁，过期时间为 time.Duration，如 LockDuration("login", 500*time.Millisecond)
+// 键名加默认构造器的前缀，设置 SetNamespace 后与未设置的旧版本互不排斥
+//
+// Deprecated: 不校验持有者，过期后可能释放其他进程的锁，请使用 Mutex
+func LockDuration(name string, expire time.Duration) bool {
+	result, err := Universal("").
+		SetNX(Ctx, NamespaceKey(KeyLock+name), 1, expire).
 		Result()
 	if err == nil && result {
 		return true
@@ -17,6 +58,200 @@ func Lock(name string, expire time.Duration) bool {
 }
 
 // Unlock 解锁
+//
+// Deprecated: 请使用 Mutex
 func Unlock(name string) {
-	Use("").Del(Ctx, KeyLock+name)
+	Universal("").Del(Ctx, NamespaceKey(KeyLock+name))
+}
+
+// Mutex 分布式锁，持有者令牌保证只能释放自己的锁
+type Mutex struct {
+	Name     string        // 锁名称
+	Client   string        // redis配置名称，留空使用default
+	Expire   time.Duration // 过期时间，默认30秒
+	Watchdog bool          // 看门狗，持有期间每 Expire/3 自动续期，直至解锁
+	RetryMin time.Duration // 阻塞加锁的最小重试间隔，默认50毫秒，之后指数退避
+	RetryMax time.Duration // 阻塞加锁的最大重试间隔，默认1秒
+
+	token string
+	stop  chan struct{}
+	mutex sync.Mutex
+}
+
+// NewMutex 分布式锁实例化
+func NewMutex(name string, expire time.Duration) *Mutex {
+	return &Mutex{Name: name, Expire: expire}
+}
+
+// TryLock 尝试加锁，不阻塞
+func (m *Mutex) TryLock(ctx context.Context) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.token != "" {
+		return false, nil
+	}
+
+	token := newToken()
+	ok, err := Universal(m.Client).SetNX(ctx, m.key(), token, m.expire()).Result()
+	if err != nil || !ok {
+		return false, err
+	}
+
+	m.token = token
+	if m.Watchdog {
+		m.stop = make(chan struct{})
+		go m.watch(m.token, m.stop)
+	}
+
+	return true, nil
+}
+
+// Lock 阻塞加锁，直至成功或ctx结束
+func (m *Mutex) Lock(ctx context.Context) error {
+	return acquire(ctx, m.RetryMin, m.RetryMax, m.TryLock)
+}
+
+// Unlock 解锁，锁已过期或被他人持有时返回 ErrLockNotHeld
+func (m *Mutex) Unlock(ctx context.Context) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.token == "" {
+		return ErrLockNotHeld
+	}
+
+	token := m.token
+	m.release()
+
+	result, err := scriptUnlock.Run(ctx, Universal(m.Client), []string{m.key()}, token).Int64()
+	if err != nil {
+		return err
+	}
+	if result == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// Refresh 续期，锁已过期或被他人持有时返回 ErrLockNotHeld
+func (m *Mutex) Refresh(ctx context.Context) error {
+	m.mutex.Lock()
+	token := m.token
+	m.mutex.Unlock()
+
+	if token == "" {
+		return ErrLockNotHeld
+	}
+
+	return m.refresh(ctx, token)
+}
+
+// Token 持有者令牌，未持有时为空
+func (m *Mutex) Token() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.token
+}
+
+func (m *Mutex) refresh(ctx context.Context, token string) error {
+	result, err := scriptRefresh.Run(ctx, Universal(m.Client), []string{m.key()}, token, m.expire().Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if result == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// watch 看门狗，续期失败且锁已不再持有时退出
+func (m *Mutex) watch(token string, stop chan struct{}) {
+	ticker := time.NewTicker(m.expire() / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), m.expire()/3)
+			err := m.refresh(ctx, token)
+			cancel()
+
+			if errors.Is(err, ErrLockNotHeld) {
+				m.mutex.Lock()
+				if m.token == token {
+					m.release()
+				}
+				m.mutex.Unlock()
+				return
+			}
+		}
+	}
+}
+
+// release 清除本地持有状态，需在 m.mutex 内调用
+func (m *Mutex) release() {
+	m.token = ""
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+}
+
+func (m *Mutex) key() string {
+	return NamespaceKey(KeyLock + m.Name)
+}
+
+func (m *Mutex) expire() time.Duration {
+	if m.Expire <= 0 {
+		return 30 * time.Second
+	}
+
+	return m.Expire
+}
+
+// newToken 随机持有者令牌
+func newToken() string {
+	bytes := make([]byte, 16)
+	_, _ = rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// acquire 阻塞加锁，指数退避重试直至成功或ctx结束
+func acquire(ctx context.Context, retryMin, retryMax time.Duration, try func(ctx context.Context) (bool, error)) error {
+	if retryMin <= 0 {
+		retryMin = 50 * time.Millisecond
+	}
+	if retryMax < retryMin {
+		retryMax = max(time.Second, retryMin)
+	}
+
+	interval := retryMin
+	for {
+		ok, err := try(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("%w: %w", ErrLockFailed, ctx.Err())
+			}
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		// 加随机抖动，避免同时重试
+		wait := interval/2 + time.Duration(mathRand.Int63n(int64(interval/2)+1))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrLockFailed, ctx.Err())
+		case <-time.After(wait):
+		}
+
+		interval = min(interval*2, retryMax)
+	}
 }
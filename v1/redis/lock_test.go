@@ -1,6 +1,8 @@
 package redis
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -189,3 +191,144 @@ func TestLockWithDifferentNames(t *testing.T) {
 	Unlock(lock1)
 	Unlock(lock2)
 }
+
+// TestLockExpireDuration 测试 Lock 单位为秒，LockDuration 为 time.Duration
+func TestLockExpireDuration(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+
+	Add("default_duration", Option{Address: []string{s.Addr()}})
+	pool.Delete("default")
+	options["default"] = options["default_duration"]
+
+	if !Lock("test_duration", 10) {
+		t.Fatal("加锁失败")
+	}
+	if ttl := s.TTL(KeyLock + "test_duration"); ttl != 10*time.Second {
+		t.Errorf("期望过期时间为10秒，实际为%v", ttl)
+	}
+
+	if !LockDuration("test_duration_ms", 1500*time.Millisecond) {
+		t.Fatal("加锁失败")
+	}
+	if ttl := s.TTL(KeyLock + "test_duration_ms"); ttl != 1500*time.Millisecond {
+		t.Errorf("期望过期时间为1.5秒，实际为%v", ttl)
+	}
+}
+
+// TestMutexOwnership 测试只能释放自己持有的锁
+func TestMutexOwnership(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("mutex_owner", Option{Address: []string{s.Addr()}})
+	pool.Delete("mutex_owner")
+
+	ctx := context.Background()
+	m1 := &Mutex{Name: "owner", Client: "mutex_owner", Expire: 10 * time.Second}
+	m2 := &Mutex{Name: "owner", Client: "mutex_owner", Expire: 10 * time.Second}
+
+	if ok, err := m1.TryLock(ctx); !ok || err != nil {
+		t.Fatalf("m1加锁失败: %v", err)
+	}
+	if s.TTL(KeyLock+"owner") != 10*time.Second {
+		t.Errorf("期望过期时间为10秒，实际为%v", s.TTL(KeyLock+"owner"))
+	}
+	if value, _ := s.Get(KeyLock + "owner"); value != m1.Token() {
+		t.Errorf("期望锁的值为持有者令牌%s，实际为%s", m1.Token(), value)
+	}
+
+	if ok, _ := m2.TryLock(ctx); ok {
+		t.Error("m2不应该加锁成功")
+	}
+	if err := m2.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("m2未持有锁，期望ErrLockNotHeld，实际为%v", err)
+	}
+
+	// 锁过期后被m2持有，m1不能释放m2的锁
+	s.FastForward(11 * time.Second)
+	if ok, _ := m2.TryLock(ctx); !ok {
+		t.Fatal("锁过期后m2应该加锁成功")
+	}
+	if err := m1.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("期望ErrLockNotHeld，实际为%v", err)
+	}
+	if !s.Exists(KeyLock + "owner") {
+		t.Error("m2的锁不应该被m1释放")
+	}
+
+	if err := m2.Unlock(ctx); err != nil {
+		t.Errorf("m2解锁失败: %v", err)
+	}
+	if s.Exists(KeyLock + "owner") {
+		t.Error("解锁后锁应该不存在")
+	}
+}
+
+// TestMutexLockBlocking 测试阻塞加锁
+func TestMutexLockBlocking(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("mutex_blocking", Option{Address: []string{s.Addr()}})
+	pool.Delete("mutex_blocking")
+
+	m1 := &Mutex{Name: "blocking", Client: "mutex_blocking"}
+	m2 := &Mutex{Name: "blocking", Client: "mutex_blocking", RetryMin: 10 * time.Millisecond, RetryMax: 20 * time.Millisecond}
+
+	if err := m1.Lock(context.Background()); err != nil {
+		t.Fatalf("m1加锁失败: %v", err)
+	}
+	if s.TTL(KeyLock+"blocking") != 30*time.Second {
+		t.Errorf("期望默认过期时间为30秒，实际为%v", s.TTL(KeyLock+"blocking"))
+	}
+
+	// 超时未获得锁
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := m2.Lock(ctx); !errors.Is(err, ErrLockFailed) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望ErrLockFailed，实际为%v", err)
+	}
+
+	// m1释放后m2获得锁
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		m1.Unlock(context.Background())
+	}()
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	if err := m2.Lock(ctx2); err != nil {
+		t.Errorf("m1释放后m2应该加锁成功: %v", err)
+	}
+	m2.Unlock(context.Background())
+}
+
+// TestMutexWatchdog 测试看门狗自动续期
+func TestMutexWatchdog(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("mutex_watchdog", Option{Address: []string{s.Addr()}})
+	pool.Delete("mutex_watchdog")
+
+	ctx := context.Background()
+	m := &Mutex{Name: "watchdog", Client: "mutex_watchdog", Expire: 300 * time.Millisecond, Watchdog: true}
+	if ok, _ := m.TryLock(ctx); !ok {
+		t.Fatal("加锁失败")
+	}
+
+	// 模拟时间流逝，看门狗续期后过期时间恢复
+	s.FastForward(200 * time.Millisecond)
+	time.Sleep(150 * time.Millisecond)
+	if ttl := s.TTL(KeyLock + "watchdog"); ttl <= 100*time.Millisecond {
+		t.Errorf("期望看门狗续期，实际过期时间为%v", ttl)
+	}
+
+	// 锁被他人抢占后，续期失败，看门狗退出并清除持有状态
+	s.Set(KeyLock+"watchdog", "other")
+	time.Sleep(150 * time.Millisecond)
+	if m.Token() != "" {
+		t.Error("锁丢失后应该清除持有状态")
+	}
+	if err := m.Refresh(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("期望ErrLockNotHeld，实际为%v", err)
+	}
+}
@@ -1,17 +1,70 @@
 package redis
 
 import (
+	"context"
 	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrNotGreater = errors.New("小于等于最大值")
+	ErrNotLess    = errors.New("大于等于最小值")
 )
 
+// 比较并设置，不存在或更优时覆盖，返回生效值与是否覆盖
+// ARGV[1] 新值，ARGV[2] max/min，ARGV[3] 过期时间（毫秒），0为永不过期
+// Lua 数字为双精度浮点数，超过2^53会丢失精度，按字符串比较：先比符号，再比长度，最后按字典序
+var scriptMaxMin = redis.NewScript(`
+local function parse(value)
+	local sign, digits = string.match(value, "^(-?)0*(%d+)$")
+	if digits == "0" then
+		sign = ""
+	end
+	return sign, digits
+end
+local function compare(value, current)
+	local valueSign, valueDigits = parse(value)
+	local currentSign, currentDigits = parse(current)
+	if valueSign ~= currentSign then
+		return valueSign == "-" and -1 or 1
+	end
+	local result = 0
+	if #valueDigits ~= #currentDigits then
+		result = #valueDigits < #currentDigits and -1 or 1
+	elseif valueDigits ~= currentDigits then
+		result = valueDigits < currentDigits and -1 or 1
+	end
+	return valueSign == "-" and -result or result
+end
+local current = redis.call("GET", KEYS[1])
+if current and not select(2, parse(current)) then
+	return redis.error_reply("ERR value is not an integer")
+end
+if not current
+	or (ARGV[2] == "max" and compare(ARGV[1], current) > 0)
+	or (ARGV[2] == "min" and compare(ARGV[1], current) < 0) then
+	if tonumber(ARGV[3]) > 0 then
+		redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[3])
+	else
+		redis.call("SET", KEYS[1], ARGV[1])
+	end
+	return {ARGV[1], 1}
+end
+return {current, 0}`)
+
 type MaxMin struct {
-	CacheKey string //缓存键名
-	Name     string //名称，CacheKey+Name=唯一标识
+	CacheKey string        //缓存键名
+	Name     string        //名称，CacheKey+Name=唯一标识
+	Client   string        //redis配置名称，留空使用default
+	Expire   time.Duration //过期时间，覆盖时重置，默认永不过期
 }
 
 // Get 获取
 func (m *MaxMin) Get() int {
-	id, err := Use("").
+	id, err := Universal(m.Client).
 		Get(Ctx, m.CacheKey+m.Name).
 		Int()
 	if err == nil {
@@ -21,41 +74,60 @@ func (m *MaxMin) Get() int {
 	return 0
 }
 
-// SetMax 设置最大值，大于才会覆盖
-func (m *MaxMin) SetMax(newId int) error {
-	key := m.CacheKey + m.Name
+// UpdateMax 原子更新最大值，不存在或大于时覆盖，返回生效的最大值与是否覆盖
+func (m *MaxMin) UpdateMax(ctx context.Context, value int) (int, bool, error) {
+	return m.update(ctx, value, "max")
+}
+
+// UpdateMin 原子更新最小值，不存在或小于时覆盖，返回生效的最小值与是否覆盖
+func (m *MaxMin) UpdateMin(ctx context.Context, value int) (int, bool, error) {
+	return m.update(ctx, value, "min")
+}
 
-	cache := Use("")
-	id, err := cache.Get(Ctx, key).Int()
-	if err != nil && err != Nil {
+// SetMax 设置最大值，大于才会覆盖，否则返回 ErrNotGreater
+func (m *MaxMin) SetMax(newId int) error {
+	_, updated, err := m.UpdateMax(Ctx, newId)
+	if err != nil {
 		return err
 	}
-
-	if newId > id {
-		return cache.Set(Ctx, key, newId, 0).Err()
-	} else {
-		return errors.New("小于等于最大值")
+	if !updated {
+		return ErrNotGreater
 	}
+
+	return nil
 }
 
-// SetMin 设置最小值，小于才会覆盖
+// SetMin 设置最小值，小于才会覆盖，否则返回 ErrNotLess
 func (m *MaxMin) SetMin(newId int) error {
-	key := m.CacheKey + m.Name
-
-	cache := Use("")
-	id, err := cache.Get(Ctx, key).Int()
-	if err != nil && err != Nil {
+	_, updated, err := m.UpdateMin(Ctx, newId)
+	if err != nil {
 		return err
 	}
-
-	if newId < id {
-		return cache.Set(Ctx, key, newId, 0).Err()
-	} else {
-		return errors.New("大于等于最小值")
+	if !updated {
+		return ErrNotLess
 	}
+
+	return nil
 }
 
 // Delete 删除
 func (m *MaxMin) Delete() {
-	Use("").Del(Ctx, m.CacheKey+m.Name)
+	Universal(m.Client).Del(Ctx, m.CacheKey+m.Name)
+}
+
+func (m *MaxMin) update(ctx context.Context, value int, mode string) (int, bool, error) {
+	result, err := scriptMaxMin.Run(ctx, Universal(m.Client), []string{m.CacheKey + m.Name},
+		value, mode, m.Expire.Milliseconds()).Slice()
+	if err != nil {
+		return 0, false, err
+	}
+
+	current, _ := result[0].(string)
+	effective, err := strconv.Atoi(current)
+	if err != nil {
+		return 0, false, err
+	}
+	updated, _ := result[1].(int64)
+
+	return effective, updated == 1, nil
 }
@@ -1,7 +1,11 @@
 package redis
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 )
@@ -245,3 +249,130 @@ func TestMaxMinEmptyName(t *testing.T) {
 
 	m.Delete()
 }
+
+// TestMaxMinUpdate 测试返回生效值，使用指定配置与过期时间
+func TestMaxMinUpdate(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("max_min_update", Option{Address: []string{s.Addr()}})
+	pool.Delete("max_min_update")
+
+	ctx := context.Background()
+	m := &MaxMin{CacheKey: "test:", Name: "update", Client: "max_min_update", Expire: time.Minute}
+
+	// 不存在时直接设置
+	if value, updated, err := m.UpdateMin(ctx, 100); err != nil || !updated || value != 100 {
+		t.Fatalf("期望设置100，实际为%d %v %v", value, updated, err)
+	}
+	if ttl := s.TTL("test:update"); ttl != time.Minute {
+		t.Errorf("期望过期时间为1分钟，实际为%v", ttl)
+	}
+
+	// 未覆盖时返回当前值，不重置过期时间
+	s.FastForward(10 * time.Second)
+	if value, updated, err := m.UpdateMin(ctx, 120); err != nil || updated || value != 100 {
+		t.Errorf("期望保持100，实际为%d %v %v", value, updated, err)
+	}
+	if ttl := s.TTL("test:update"); ttl != 50*time.Second {
+		t.Errorf("未覆盖时不应该重置过期时间，实际为%v", ttl)
+	}
+	if err := m.SetMin(120); !errors.Is(err, ErrNotLess) {
+		t.Errorf("期望ErrNotLess，实际为%v", err)
+	}
+
+	if value, updated, _ := m.UpdateMin(ctx, -5); !updated || value != -5 {
+		t.Errorf("期望覆盖为-5，实际为%d %v", value, updated)
+	}
+	if m.Get() != -5 {
+		t.Errorf("期望获取-5，实际获取%d", m.Get())
+	}
+
+	// 非整数值返回错误
+	s.Set("test:update", "abc")
+	if _, _, err := m.UpdateMax(ctx, 1); err == nil {
+		t.Error("非整数值应该返回错误")
+	}
+}
+
+// TestMaxMinLargeValue 测试超过2^53的值按精确整数比较，如雪花ID
+func TestMaxMinLargeValue(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("max_min_large", Option{Address: []string{s.Addr()}})
+	pool.Delete("max_min_large")
+
+	ctx := context.Background()
+	m := &MaxMin{CacheKey: "test:", Name: "large", Client: "max_min_large"}
+
+	// 双精度浮点数下 2^53+1 与 2^53 相等
+	if _, updated, err := m.UpdateMax(ctx, 1<<53); err != nil || !updated {
+		t.Fatalf("期望设置2^53，实际为%v %v", updated, err)
+	}
+	if value, updated, _ := m.UpdateMax(ctx, 1<<53+1); !updated || value != 1<<53+1 {
+		t.Errorf("期望覆盖为2^53+1，实际为%d %v", value, updated)
+	}
+	if value, updated, _ := m.UpdateMax(ctx, 1<<53); updated || value != 1<<53+1 {
+		t.Errorf("期望保持2^53+1，实际为%d %v", value, updated)
+	}
+	if value, updated, _ := m.UpdateMax(ctx, 1815467432891129856); !updated || value != 1815467432891129856 {
+		t.Errorf("期望覆盖为雪花ID，实际为%d %v", value, updated)
+	}
+
+	// 负数与非规范写法
+	m.Delete()
+	if _, updated, _ := m.UpdateMin(ctx, -(1 << 53)); !updated {
+		t.Error("期望设置-2^53")
+	}
+	if value, updated, _ := m.UpdateMin(ctx, -(1<<53)-1); !updated || value != -(1<<53)-1 {
+		t.Errorf("期望覆盖为-2^53-1，实际为%d %v", value, updated)
+	}
+	if _, updated, _ := m.UpdateMin(ctx, 0); updated {
+		t.Error("0不应小于负数")
+	}
+
+	s.Set("test:large", "-0")
+	if _, updated, _ := m.UpdateMax(ctx, 0); updated {
+		t.Error("0不应大于-0")
+	}
+	s.Set("test:large", "0010")
+	if value, updated, _ := m.UpdateMax(ctx, 9); updated || value != 10 {
+		t.Errorf("期望保持10，实际为%d %v", value, updated)
+	}
+	if _, updated, _ := m.UpdateMax(ctx, 11); !updated {
+		t.Error("期望11覆盖0010")
+	}
+}
+
+// TestMaxMinConcurrent 测试并发更新不会被较小值覆盖
+func TestMaxMinConcurrent(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("max_min_concurrent", Option{Address: []string{s.Addr()}})
+	pool.Delete("max_min_concurrent")
+
+	ctx := context.Background()
+	maxValue := &MaxMin{CacheKey: "test:", Name: "concurrent_max", Client: "max_min_concurrent"}
+	minValue := &MaxMin{CacheKey: "test:", Name: "concurrent_min", Client: "max_min_concurrent"}
+
+	var wait sync.WaitGroup
+	for i := 1; i <= 200; i++ {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			if _, _, err := maxValue.UpdateMax(ctx, i); err != nil {
+				t.Error(err)
+			}
+			if _, _, err := minValue.UpdateMin(ctx, i); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wait.Wait()
+
+	if value := maxValue.Get(); value != 200 {
+		t.Errorf("期望最大值200，实际为%d", value)
+	}
+	if value := minValue.Get(); value != 1 {
+		t.Errorf("期望最小值1，实际为%d", value)
+	}
+}
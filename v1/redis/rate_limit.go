@@ -0,0 +1,226 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrRateLimitInvalid = errors.New("redis: rate limit invalid")
+
+// 脚本内使用 Redis 服务端时间，避免各实例时钟不一致
+// 返回 {是否允许, 剩余次数, 重试等待毫秒, 恢复满额毫秒}，允许时重试等待为-1
+var (
+	// 固定窗口，ARGV[1] 次数，ARGV[2] 窗口毫秒
+	scriptFixedWindow = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	ttl = tonumber(ARGV[2])
+end
+if count > limit then
+	return {0, 0, ttl, ttl}
+end
+return {1, limit - count, -1, ttl}`)
+
+	// 滑动日志，ARGV[1] 次数，ARGV[2] 窗口毫秒，ARGV[3] 请求唯一标识
+	scriptSlidingLog = redis.NewScript(`
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + tonumber(time[2]) / 1000
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+if count < limit then
+	redis.call("ZADD", KEYS[1], now, ARGV[3])
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return {1, limit - count - 1, -1, window}
+end
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+local newest = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+local retry = math.max(math.ceil(tonumber(oldest[2]) + window - now), 1)
+local reset = math.max(math.ceil(tonumber(newest[2]) + window - now), 1)
+return {0, 0, retry, reset}`)
+
+	// 令牌桶，ARGV[1] 周期内补充的令牌数，ARGV[2] 周期毫秒，ARGV[3] 桶容量
+	scriptTokenBucket = redis.NewScript(`
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + tonumber(time[2]) / 1000
+local rate = tonumber(ARGV[1]) / tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1]) or burst
+local ts = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+local retry = -1
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.max(math.ceil((1 - tokens) / rate), 1)
+end
+local reset = math.ceil((burst - tokens) / rate)
+redis.call("HSET", KEYS[1], "tokens", string.format("%.6f", tokens), "ts", string.format("%.3f", now))
+redis.call("PEXPIRE", KEYS[1], math.max(reset, 1))
+return {allowed, math.floor(tokens), retry, reset}`)
+
+	// GCRA，保存理论到达时间，ARGV[1] 周期内次数，ARGV[2] 周期毫秒，ARGV[3] 突发容量
+	scriptGCRA = redis.NewScript(`
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + tonumber(time[2]) / 1000
+local emission = tonumber(ARGV[2]) / tonumber(ARGV[1])
+local burstOffset = emission * tonumber(ARGV[3])
+local tat = tonumber(redis.call("GET", KEYS[1])) or now
+tat = math.max(tat, now)
+local newTat = tat + emission
+local diff = now - (newTat - burstOffset)
+if diff < 0 then
+	return {0, 0, math.max(math.ceil(-diff), 1), math.ceil(tat - now)}
+end
+local reset = math.max(math.ceil(newTat - now), 1)
+redis.call("SET", KEYS[1], string.format("%.3f", newTat), "PX", reset)
+return {1, math.floor(diff / emission), -1, reset}`)
+)
+
+// RateLimitResult 限流结果
+type RateLimitResult struct {
+	Allowed    bool          // 是否允许
+	Limit      int           // 配额
+	Remaining  int           // 剩余次数
+	RetryAfter time.Duration // 被拒绝时，距离下次允许的等待时间
+	ResetAfter time.Duration // 配额完全恢复的时间
+}
+
+// Limiter 限流器，key 为限流对象，比如用户ID、IP
+type Limiter interface {
+	Allow(ctx context.Context, key string) (RateLimitResult, error)
+}
+
+// FixedWindow 固定窗口，窗口内最多 Limit 次，实现简单，窗口交界处可能出现两倍突发
+type FixedWindow struct {
+	Client string        // redis配置名称，留空使用default
+	Limit  int           // 窗口内次数
+	Window time.Duration // 窗口
+}
+
+// NewFixedWindow 固定窗口实例化
+func NewFixedWindow(limit int, window time.Duration) *FixedWindow {
+	return &FixedWindow{Limit: limit, Window: window}
+}
+
+func (l *FixedWindow) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	if l.Limit <= 0 || l.Window <= 0 {
+		return RateLimitResult{}, ErrRateLimitInvalid
+	}
+
+	return runLimiter(ctx, l.Client, scriptFixedWindow, KeyRateLimit+"fixed:"+key, l.Limit,
+		l.Limit, l.Window.Milliseconds())
+}
+
+// SlidingLog 滑动日志，记录窗口内每次请求的时间，精确但占用内存与次数成正比
+type SlidingLog struct {
+	Client string        // redis配置名称，留空使用default
+	Limit  int           // 窗口内次数
+	Window time.Duration // 窗口
+}
+
+// NewSlidingLog 滑动日志实例化
+func NewSlidingLog(limit int, window time.Duration) *SlidingLog {
+	return &SlidingLog{Limit: limit, Window: window}
+}
+
+func (l *SlidingLog) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	if l.Limit <= 0 || l.Window <= 0 {
+		return RateLimitResult{}, ErrRateLimitInvalid
+	}
+
+	return runLimiter(ctx, l.Client, scriptSlidingLog, KeyRateLimit+"sliding:"+key, l.Limit,
+		l.Limit, l.Window.Milliseconds(), newToken())
+}
+
+// TokenBucket 令牌桶，每 Period 匀速补充 Limit 个令牌，最多积攒 Burst 个
+type TokenBucket struct {
+	Client string        // redis配置名称，留空使用default
+	Limit  int           // 周期内补充的令牌数
+	Period time.Duration // 周期
+	Burst  int           // 桶容量，默认等于 Limit
+}
+
+// NewTokenBucket 令牌桶实例化
+func NewTokenBucket(limit int, period time.Duration, burst int) *TokenBucket {
+	return &TokenBucket{Limit: limit, Period: period, Burst: burst}
+}
+
+func (l *TokenBucket) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	if l.Limit <= 0 || l.Period <= 0 {
+		return RateLimitResult{}, ErrRateLimitInvalid
+	}
+
+	burst := l.Burst
+	if burst <= 0 {
+		burst = l.Limit
+	}
+
+	return runLimiter(ctx, l.Client, scriptTokenBucket, KeyRateLimit+"bucket:"+key, burst,
+		l.Limit, l.Period.Milliseconds(), burst)
+}
+
+// GCRA 通用信元速率算法，效果等同漏桶，每个key只保存一个时间戳
+type GCRA struct {
+	Client string        // redis配置名称，留空使用default
+	Limit  int           // 周期内次数
+	Period time.Duration // 周期
+	Burst  int           // 突发容量，默认等于 Limit
+}
+
+// NewGCRA GCRA实例化
+func NewGCRA(limit int, period time.Duration, burst int) *GCRA {
+	return &GCRA{Limit: limit, Period: period, Burst: burst}
+}
+
+func (l *GCRA) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	if l.Limit <= 0 || l.Period <= 0 {
+		return RateLimitResult{}, ErrRateLimitInvalid
+	}
+
+	burst := l.Burst
+	if burst <= 0 {
+		burst = l.Limit
+	}
+
+	return runLimiter(ctx, l.Client, scriptGCRA, KeyRateLimit+"gcra:"+key, burst,
+		l.Limit, l.Period.Milliseconds(), burst)
+}
+
+// runLimiter 执行限流脚本，键名加默认构造器的前缀
+func runLimiter(ctx context.Context, client string, script *redis.Script, key string, limit int, args ...interface{}) (RateLimitResult, error) {
+	redisClient, err := Get(ctx, client)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	values, err := script.Run(ctx, redisClient, []string{NamespaceKey(key)}, args...).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	result := RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}
+	if values[2] > 0 {
+		result.RetryAfter = time.Duration(values[2]) * time.Millisecond
+	}
+
+	return result, nil
+}
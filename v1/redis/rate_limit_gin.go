@@ -0,0 +1,83 @@
+package redis
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lynnclub/go/v1/ip"
+	"github.com/lynnclub/go/v1/logger"
+	"github.com/lynnclub/go/v1/response"
+)
+
+// RateLimitKey 限流对象，返回空时不限流
+type RateLimitKey func(c *gin.Context) string
+
+// RateLimitByIP 按客户端IP限流，trustedHeaders 同 ip.GetClients
+func RateLimitByIP(trustedHeaders ...string) RateLimitKey {
+	return func(c *gin.Context) string {
+		if ips := ip.GetClients(c.Request, trustedHeaders...); len(ips) > 0 {
+			return "ip:" + ips[0]
+		}
+		return ""
+	}
+}
+
+// RateLimitByUser 按用户ID限流，从 gin 上下文读取，未登录时按IP限流
+func RateLimitByUser(contextKey string, trustedHeaders ...string) RateLimitKey {
+	byIP := RateLimitByIP(trustedHeaders...)
+	return func(c *gin.Context) string {
+		if value, ok := c.Get(contextKey); ok {
+			switch userId := value.(type) {
+			case string:
+				if userId != "" {
+					return "user:" + userId
+				}
+			case int:
+				return "user:" + strconv.Itoa(userId)
+			case int64:
+				return "user:" + strconv.FormatInt(userId, 10)
+			}
+		}
+		return byIP(c)
+	}
+}
+
+// RateLimitMiddleware 限流中间件，name 区分不同接口的配额
+// 设置 RateLimit-Limit、RateLimit-Remaining、RateLimit-Reset 头，被拒绝时设置 Retry-After 并响应429
+// Redis 故障时放行，避免限流影响业务
+func RateLimitMiddleware(name string, limiter Limiter, key RateLimitKey) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := key(c)
+		if id == "" {
+			c.Next()
+			return
+		}
+
+		result, err := limiter.Allow(c.Request.Context(), name+":"+id)
+		if err != nil {
+			logger.Error("限流失败", name, err.Error())
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", ceilSeconds(result.ResetAfter))
+
+		if !result.Allowed {
+			c.Header("Retry-After", ceilSeconds(result.RetryAfter))
+			response.JsonCode(c, http.StatusTooManyRequests, http.StatusTooManyRequests, "请求过于频繁，请稍后再试")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ceilSeconds 向上取整的秒数
+func ceilSeconds(duration time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(duration.Seconds())))
+}
@@ -0,0 +1,114 @@
+package redis
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRateLimitMiddleware 测试按IP限流中间件
+func TestRateLimitMiddleware(t *testing.T) {
+	setupRedis(t, "rate_gin")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	limiter := &FixedWindow{Client: "rate_gin", Limit: 2, Window: time.Minute}
+	router.GET("/api", RateLimitMiddleware("api", limiter, RateLimitByIP()), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	request := func(ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api", nil)
+		req.Header.Set("X-Forwarded-For", ip)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("1.1.1.1")
+	if w.Body.String() != "ok" {
+		t.Fatalf("期望放行，实际为%s", w.Body.String())
+	}
+	if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != "1" || w.Header().Get("RateLimit-Reset") != "60" {
+		t.Errorf("限流头错误 %v", w.Header())
+	}
+
+	request("1.1.1.1")
+	w = request("1.1.1.1")
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), `"status":429`) {
+		t.Errorf("期望429，实际为%d %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Retry-After") != "60" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("限流头错误 %v", w.Header())
+	}
+
+	// 其他IP不受影响
+	if w = request("2.2.2.2"); w.Body.String() != "ok" {
+		t.Errorf("其他IP应该放行，实际为%s", w.Body.String())
+	}
+}
+
+// TestRateLimitByUser 测试按用户限流，未登录按IP
+func TestRateLimitByUser(t *testing.T) {
+	key := RateLimitByUser("user_id")
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	c.Request.RemoteAddr = "3.3.3.3:1234"
+
+	if id := key(c); id != "ip:3.3.3.3" {
+		t.Errorf("未登录期望按IP，实际为%s", id)
+	}
+
+	c.Set("user_id", 10086)
+	if id := key(c); id != "user:10086" {
+		t.Errorf("期望按用户，实际为%s", id)
+	}
+}
+
+// TestRateLimitMiddlewareFailOpen 测试Redis故障时放行
+func TestRateLimitMiddlewareFailOpen(t *testing.T) {
+	s := setupRedis(t, "rate_gin_fail")
+	Use("rate_gin_fail")
+	s.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	limiter := &GCRA{Client: "rate_gin_fail", Limit: 1, Period: time.Second}
+	router.GET("/api", RateLimitMiddleware("api", limiter, RateLimitByIP()), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api", nil)
+	req.RemoteAddr = "4.4.4.4:1234"
+	router.ServeHTTP(w, req)
+	if w.Body.String() != "ok" {
+		t.Errorf("Redis故障时应该放行，实际为%s", w.Body.String())
+	}
+}
+
+// TestRateLimitMiddlewareColdPool 测试连接未建立且 Redis 不可用时放行，不panic
+func TestRateLimitMiddlewareColdPool(t *testing.T) {
+	s := setupRedis(t, "rate_gin_cold")
+	s.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	limiter := &GCRA{Client: "rate_gin_cold", Limit: 1, Period: time.Second}
+	router.GET("/api", RateLimitMiddleware("api", limiter, RateLimitByIP()), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api", nil)
+	req.RemoteAddr = "5.5.5.5:1234"
+	router.ServeHTTP(w, req)
+	if w.Body.String() != "ok" {
+		t.Errorf("连接失败时应该放行，实际为%s", w.Body.String())
+	}
+}
@@ -0,0 +1,157 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// allowN 连续请求n次，返回允许次数与最后一次结果
+func allowN(t *testing.T, limiter Limiter, key string, n int) (int, RateLimitResult) {
+	allowed := 0
+	var result RateLimitResult
+	for i := 0; i < n; i++ {
+		var err error
+		if result, err = limiter.Allow(context.Background(), key); err != nil {
+			t.Fatalf("限流失败: %v", err)
+		}
+		if result.Allowed {
+			allowed++
+		}
+	}
+
+	return allowed, result
+}
+
+// TestFixedWindow 测试固定窗口
+func TestFixedWindow(t *testing.T) {
+	s := setupRedis(t, "rate_fixed")
+	limiter := &FixedWindow{Client: "rate_fixed", Limit: 3, Window: time.Minute}
+
+	result, _ := limiter.Allow(context.Background(), "user")
+	if !result.Allowed || result.Remaining != 2 || result.Limit != 3 || result.ResetAfter != time.Minute {
+		t.Errorf("首次请求结果错误 %+v", result)
+	}
+
+	allowed, result := allowN(t, limiter, "user", 5)
+	if allowed != 2 {
+		t.Errorf("期望再允许2次，实际为%d", allowed)
+	}
+	if result.Allowed || result.Remaining != 0 || result.RetryAfter != time.Minute {
+		t.Errorf("超限结果错误 %+v", result)
+	}
+
+	// 其他key不受影响
+	if result, _ := limiter.Allow(context.Background(), "other"); !result.Allowed {
+		t.Error("其他key应该允许")
+	}
+
+	// 窗口过期后恢复
+	s.FastForward(time.Minute)
+	if result, _ := limiter.Allow(context.Background(), "user"); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("窗口过期后应该恢复 %+v", result)
+	}
+}
+
+// TestSlidingLog 测试滑动日志
+func TestSlidingLog(t *testing.T) {
+	s := setupRedis(t, "rate_sliding")
+	limiter := NewSlidingLog(3, 10*time.Second)
+	limiter.Client = "rate_sliding"
+
+	now := time.Now()
+	s.SetTime(now)
+	allowN(t, limiter, "user", 2)
+
+	s.SetTime(now.Add(6 * time.Second))
+	allowed, result := allowN(t, limiter, "user", 2)
+	if allowed != 1 || result.Allowed {
+		t.Fatalf("期望允许1次后拒绝，实际为%d %+v", allowed, result)
+	}
+	// 最早的请求在第10秒过期
+	if result.RetryAfter != 4*time.Second {
+		t.Errorf("期望等待4秒，实际为%v", result.RetryAfter)
+	}
+	if result.ResetAfter != 10*time.Second {
+		t.Errorf("期望10秒后完全恢复，实际为%v", result.ResetAfter)
+	}
+
+	// 固定窗口在此处会重置，滑动窗口仍只释放过期的请求
+	s.SetTime(now.Add(11 * time.Second))
+	allowed, _ = allowN(t, limiter, "user", 3)
+	if allowed != 2 {
+		t.Errorf("期望允许2次，实际为%d", allowed)
+	}
+}
+
+// TestTokenBucket 测试令牌桶
+func TestTokenBucket(t *testing.T) {
+	s := setupRedis(t, "rate_bucket")
+	// 每秒补充1个，最多积攒5个
+	limiter := &TokenBucket{Client: "rate_bucket", Limit: 1, Period: time.Second, Burst: 5}
+
+	now := time.Now()
+	s.SetTime(now)
+	allowed, result := allowN(t, limiter, "user", 6)
+	if allowed != 5 || result.Allowed {
+		t.Fatalf("期望突发5次后拒绝，实际为%d %+v", allowed, result)
+	}
+	if result.RetryAfter != time.Second || result.ResetAfter != 5*time.Second {
+		t.Errorf("等待时间错误 %+v", result)
+	}
+
+	// 2.5秒后补充2个
+	s.SetTime(now.Add(2500 * time.Millisecond))
+	allowed, _ = allowN(t, limiter, "user", 3)
+	if allowed != 2 {
+		t.Errorf("期望允许2次，实际为%d", allowed)
+	}
+
+	// 长时间后不超过容量
+	s.SetTime(now.Add(time.Hour))
+	allowed, _ = allowN(t, limiter, "user", 10)
+	if allowed != 5 {
+		t.Errorf("期望最多积攒5个，实际为%d", allowed)
+	}
+}
+
+// TestGCRA 测试GCRA
+func TestGCRA(t *testing.T) {
+	s := setupRedis(t, "rate_gcra")
+	// 每秒10次，突发2次
+	limiter := NewGCRA(10, time.Second, 2)
+	limiter.Client = "rate_gcra"
+
+	now := time.Now()
+	s.SetTime(now)
+	result, _ := limiter.Allow(context.Background(), "user")
+	if !result.Allowed || result.Remaining != 1 || result.Limit != 2 {
+		t.Errorf("首次请求结果错误 %+v", result)
+	}
+
+	allowed, result := allowN(t, limiter, "user", 3)
+	if allowed != 1 || result.Allowed {
+		t.Fatalf("期望允许1次后拒绝，实际为%d %+v", allowed, result)
+	}
+	if result.RetryAfter != 100*time.Millisecond {
+		t.Errorf("期望等待100毫秒，实际为%v", result.RetryAfter)
+	}
+
+	// 按发射间隔匀速放行
+	s.SetTime(now.Add(100 * time.Millisecond))
+	allowed, _ = allowN(t, limiter, "user", 2)
+	if allowed != 1 {
+		t.Errorf("期望允许1次，实际为%d", allowed)
+	}
+}
+
+// TestRateLimitInvalid 测试无效配置
+func TestRateLimitInvalid(t *testing.T) {
+	limiters := []Limiter{&FixedWindow{}, &SlidingLog{}, &TokenBucket{}, &GCRA{}}
+	for _, limiter := range limiters {
+		if _, err := limiter.Allow(context.Background(), "user"); !errors.Is(err, ErrRateLimitInvalid) {
+			t.Errorf("%T 期望ErrRateLimitInvalid，实际为%v", limiter, err)
+		}
+	}
+}
@@ -1,7 +1,8 @@
 package redis
 
 import (
-	"crypto/tls"
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -13,47 +14,75 @@ var (
 	mutexCluster sync.Mutex    //互斥锁
 )
 
-// Cluster 使用集群
+// Cluster 使用集群，失败时panic
 func Cluster(name string) *redis.ClusterClient {
+	return must(GetCluster(Ctx, name))
+}
+
+// GetCluster 使用集群，不存在时新建连接，失败时返回错误且不缓存
+func GetCluster(ctx context.Context, name string) (*redis.ClusterClient, error) {
+	if instance, ok := poolCluster.Load(name); ok {
+		return instance.(*redis.ClusterClient), nil
+	}
+
+	mutexCluster.Lock()
+	defer mutexCluster.Unlock()
 	if instance, ok := poolCluster.Load(name); ok {
-		return instance.(*redis.ClusterClient)
-	} else {
-		mutexCluster.Lock()
-		defer mutexCluster.Unlock()
-		if instance, ok = pool.Load(name); ok {
-			return instance.(*redis.ClusterClient)
-		}
+		return instance.(*redis.ClusterClient), nil
 	}
 
-	option, ok := options[name]
+	option, ok := getOption(name)
 	if !ok {
-		panic("Option not found " + name)
+		return nil, errors.New("Option not found " + name)
+	}
+
+	newClient, err := dialCluster(ctx, name, option)
+	if err != nil {
+		return nil, err
 	}
 
+	poolCluster.Store(name, newClient)
+	return newClient, nil
+}
+
+// dialCluster 新建连接并Ping，不缓存
+func dialCluster(ctx context.Context, name string, option Option) (*redis.ClusterClient, error) {
 	// 默认输出到 stderr，纠正为 stdout
 	redis.SetLogger(newStdoutLogger())
 
+	tlsConfig, err := newTLSConfig(option)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect redis cluster %s err: %w", name, err)
+	}
+
 	clusterOptions := &redis.ClusterOptions{
 		Addrs:           option.Address,
+		Username:        option.Username,
 		Password:        option.Password,
 		PoolSize:        option.PoolSize,
 		MinIdleConns:    option.MinIdleConns,
 		MaxIdleConns:    option.MaxIdleConns,
 		ConnMaxIdleTime: option.ConnMaxIdleTime,
-	}
-	if option.TLS {
-		clusterOptions.TLSConfig = &tls.Config{}
+		TLSConfig:       tlsConfig,
+		DialTimeout:     option.DialTimeout,
+		ReadTimeout:     option.ReadTimeout,
+		WriteTimeout:    option.WriteTimeout,
+		MaxRetries:      option.MaxRetries,
+		MinRetryBackoff: option.MinRetryBackoff,
+		MaxRetryBackoff: option.MaxRetryBackoff,
 	}
 
 	newClient := redis.NewClusterClient(clusterOptions)
+	if !option.DisableHook {
+		newClient.AddHook(NewHook(name, option))
+	}
 
-	info, err := newClient.Ping(Ctx).Result()
-	if err == nil {
-		fmt.Println("Connected to redis cluster", name, info)
-	} else {
-		panic("Failed to connect redis cluster " + name + " err: " + err.Error())
+	info, err := newClient.Ping(ctx).Result()
+	if err != nil {
+		_ = newClient.Close()
+		return nil, fmt.Errorf("Failed to connect redis cluster %s err: %w", name, err)
 	}
+	fmt.Println("Connected to redis cluster", name, info)
 
-	poolCluster.Store(name, newClient)
-	return newClient
+	return newClient, nil
 }
@@ -1,6 +1,114 @@
 package redis
 
+import (
+	"strings"
+	"sync/atomic"
+)
+
 const (
-	KeyBase = "general:"        //基础
-	KeyLock = KeyBase + "lock:" //锁
+	KeyBase       = "general:"               //基础
+	KeyLock       = KeyBase + "lock:"        //锁
+	KeyRateLimit  = KeyBase + "rate_limit:"  //限流
+	KeyDelayQueue = KeyBase + "delay_queue:" //延迟队列
 )
+
+var namespace atomic.Pointer[KeyBuilder] //默认键名构造器
+
+// KeyBuilder 键名构造器，按应用、环境加前缀，多个应用或环境共用实例时避免键名冲突
+// 格式 app:env:part1:part2，为空的部分省略
+type KeyBuilder struct {
+	App string //应用名
+	Env string //环境，建议使用 config.Env
+}
+
+// NewKeyBuilder 实例化
+func NewKeyBuilder(app, env string) *KeyBuilder {
+	return &KeyBuilder{App: app, Env: env}
+}
+
+// Prefix 前缀，App、Env 均为空时为空
+func (b *KeyBuilder) Prefix() string {
+	var prefix strings.Builder
+	for _, part := range []string{b.App, b.Env} {
+		if part != "" {
+			prefix.WriteString(part)
+			prefix.WriteString(":")
+		}
+	}
+
+	return prefix.String()
+}
+
+// Key 拼接键名，各部分以冒号分隔
+func (b *KeyBuilder) Key(parts ...string) string {
+	return b.Prefix() + strings.Join(parts, ":")
+}
+
+// Pattern 匹配该前缀下键名的 SCAN 模式，前缀与各部分中的通配符会被转义
+// 如 Pattern("user") 匹配 app:env:user:*
+func (b *KeyBuilder) Pattern(parts ...string) string {
+	key := b.Key(parts...)
+	if len(parts) > 0 {
+		key += ":"
+	}
+
+	return escapePattern(key) + "*"
+}
+
+// SetNamespace 设置默认键名构造器的应用名与环境，应在启动时、使用 redis 之前设置
+// 内置工具（锁、限流、延迟队列、领导者选举等）的键名同样加前缀
+// 如 redis.SetNamespace(config.Viper.GetString("app"), config.Env)
+func SetNamespace(app, env string) {
+	namespace.Store(NewKeyBuilder(app, env))
+}
+
+// SetNamespaceMap 使用 map 设置默认键名构造器，键为 app、env
+// 如 redis.SetNamespaceMap(config.Viper.GetStringMap("redis_namespace"))
+func SetNamespaceMap(setting map[string]interface{}) {
+	app, _ := setting["app"].(string)
+	env, _ := setting["env"].(string)
+	SetNamespace(app, env)
+}
+
+// Namespace 默认键名构造器，未设置时没有前缀
+func Namespace() *KeyBuilder {
+	if builder := namespace.Load(); builder != nil {
+		return builder
+	}
+
+	return &KeyBuilder{}
+}
+
+// Key 使用默认键名构造器拼接键名
+func Key(parts ...string) string {
+	return Namespace().Key(parts...)
+}
+
+// NamespaceKey 内置键名加默认构造器的前缀，如 app:env:general:lock:name，未设置时不变
+func NamespaceKey(key string) string {
+	return Namespace().Prefix() + key
+}
+
+// Pattern 使用默认键名构造器生成 SCAN 模式
+func Pattern(parts ...string) string {
+	return Namespace().Pattern(parts...)
+}
+
+// HashTag 哈希标签，cluster 模式下相同标签的键位于同一槽位，可用于多键命令与脚本
+func HashTag(tag string) string {
+	return "{" + tag + "}"
+}
+
+// escapePattern 转义 glob 通配符
+func escapePattern(pattern string) string {
+	var escaped strings.Builder
+	for _, char := range pattern {
+		switch char {
+		case '*', '?', '[', ']', '\\':
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(char)
+	}
+
+	return escaped.String()
+}
@@ -1,7 +1,9 @@
 package redis
 
 import (
+	"context"
 	"testing"
+	"time"
 )
 
 // TestKeyConstants 测试键常量定义
@@ -78,3 +80,70 @@ func TestKeyUniqueness(t *testing.T) {
 		t.Error("KeyBase和KeyLock不应该相同")
 	}
 }
+
+// TestKeyBuilder 测试按应用、环境拼接键名
+func TestKeyBuilder(t *testing.T) {
+	builder := NewKeyBuilder("shop", "test")
+	if key := builder.Key("user", "1"); key != "shop:test:user:1" {
+		t.Errorf("键名拼接错误 %s", key)
+	}
+	if key := NewKeyBuilder("shop", "").Key("user"); key != "shop:user" {
+		t.Errorf("环境为空时应该省略 %s", key)
+	}
+	if pattern := NewKeyBuilder("a*b", "test").Pattern("user"); pattern != `a\*b:test:user:*` {
+		t.Errorf("模式错误 %s", pattern)
+	}
+	if pattern := builder.Pattern(); pattern != "shop:test:*" {
+		t.Errorf("模式错误 %s", pattern)
+	}
+	if tag := HashTag("order"); tag != "{order}" {
+		t.Errorf("哈希标签错误 %s", tag)
+	}
+
+	// 默认构造器
+	if key := Key("user", "1"); key != "user:1" {
+		t.Errorf("未设置时不应该有前缀 %s", key)
+	}
+	SetNamespace("shop", "dev")
+	defer namespace.Store(nil)
+	if key := Key("user", "1"); key != "shop:dev:user:1" || Pattern("user") != "shop:dev:user:*" {
+		t.Errorf("默认构造器错误 %s %s", key, Pattern("user"))
+	}
+}
+
+// TestNamespaceBuiltinKeys 测试内置工具的键名加默认构造器的前缀
+func TestNamespaceBuiltinKeys(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("test_namespace", Option{Address: []string{s.Addr()}})
+	pool.Delete("test_namespace")
+
+	if key := NamespaceKey(KeyLock + "order"); key != "general:lock:order" {
+		t.Errorf("未设置时内置键名不应该变化 %s", key)
+	}
+
+	SetNamespaceMap(map[string]interface{}{"app": "shop", "env": "dev"})
+	defer namespace.Store(nil)
+
+	ctx := context.Background()
+	mutex := &Mutex{Name: "order", Client: "test_namespace", Expire: time.Minute}
+	if ok, err := mutex.TryLock(ctx); !ok || err != nil {
+		t.Fatalf("加锁失败 %v", err)
+	}
+	if !s.Exists("shop:dev:general:lock:order") {
+		t.Errorf("锁键名期望加前缀 %v", s.Keys())
+	}
+
+	limiter := &FixedWindow{Client: "test_namespace", Limit: 1, Window: time.Minute}
+	if _, err := limiter.Allow(ctx, "ip:1"); err != nil {
+		t.Fatalf("限流失败 %v", err)
+	}
+	if !s.Exists("shop:dev:general:rate_limit:fixed:ip:1") {
+		t.Errorf("限流键名期望加前缀 %v", s.Keys())
+	}
+
+	leader := &Leader{Name: "cron", Client: "test_namespace"}
+	if key := leader.key(); key != "shop:dev:general:leader:{cron}" {
+		t.Errorf("选举键名期望加前缀 %s", key)
+	}
+}
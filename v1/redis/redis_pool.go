@@ -2,37 +2,94 @@ package redis
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/lynnclub/go/v1/lifecycle"
 	"github.com/redis/go-redis/v9"
 )
 
 var (
-	pool    = &sync.Map{}             //实例池
-	mutex   sync.Mutex                //互斥锁
-	options = make(map[string]Option) //配置池
-	Ctx     = context.Background()
-	Nil     = redis.Nil
+	pool         = &sync.Map{}             //实例池
+	mutex        sync.Mutex                //互斥锁
+	options      = make(map[string]Option) //配置池
+	optionsMutex sync.RWMutex              //配置池读写锁，Reload 时修改
+	register     sync.Once                 //注册到生命周期管理
+	Ctx          = context.Background()
+	Nil          = redis.Nil
+)
+
+// 部署模式
+const (
+	ModeStandalone = "standalone" //单机，默认
+	ModeSentinel   = "sentinel"   //Sentinel集群
+	ModeCluster    = "cluster"    //Cluster集群
 )
 
 type Option struct {
-	Address         []string      `json:"address"`            //地址，字符串数组
-	Password        string        `json:"password"`           //密码，默认空
-	DB              int           `json:"db"`                 //db
-	PoolSize        int           `json:"pool_size"`          //连接池最大数量，默认100
-	MinIdleConns    int           `json:"min_idle_conns"`     //最小空闲连接数，默认0
-	MaxIdleConns    int           `json:"max_idle_conns"`     //最大空闲连接数，默认0（无限制）
-	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time"` //连接最大空闲时间，默认5分钟
-	MasterName      string        `json:"master_name"`        //Sentinel集群模式，主库名称，默认mymaster
-	TLS             bool          `json:"tls"`                //是否启用TLS，默认使用系统根证书
+	Mode               string        `json:"mode"`                 //部署模式，standalone、sentinel、cluster，默认standalone
+	Address            []string      `json:"address"`              //地址，字符串数组
+	Username           string        `json:"username"`             //ACL用户名，默认空（default用户）
+	Password           string        `json:"password"`             //密码，默认空
+	DB                 int           `json:"db"`                   //db
+	PoolSize           int           `json:"pool_size"`            //连接池最大数量，默认100
+	MinIdleConns       int           `json:"min_idle_conns"`       //最小空闲连接数，默认0
+	MaxIdleConns       int           `json:"max_idle_conns"`       //最大空闲连接数，默认0（无限制）
+	ConnMaxIdleTime    time.Duration `json:"conn_max_idle_time"`   //连接最大空闲时间，默认5分钟
+	MasterName         string        `json:"master_name"`          //Sentinel集群模式，主库名称，默认mymaster
+	TLS                bool          `json:"tls"`                  //是否启用TLS，默认使用系统根证书，设置证书相关配置时自动启用
+	TLSCertFile        string        `json:"tls_cert_file"`        //客户端证书路径，需与 TLSKeyFile 同时设置
+	TLSKeyFile         string        `json:"tls_key_file"`         //客户端私钥路径
+	TLSCAFile          string        `json:"tls_ca_file"`          //CA证书路径，默认使用系统根证书
+	TLSServerName      string        `json:"tls_server_name"`      //校验的服务端名称，默认取地址中的主机名
+	InsecureSkipVerify bool          `json:"insecure_skip_verify"` //不校验服务端证书，仅用于测试
+	DialTimeout        time.Duration `json:"dial_timeout"`         //建立连接超时，默认5秒
+	ReadTimeout        time.Duration `json:"read_timeout"`         //读超时，默认3秒，负数不超时
+	WriteTimeout       time.Duration `json:"write_timeout"`        //写超时，默认同读超时，负数不超时
+	MaxRetries         int           `json:"max_retries"`          //命令失败最大重试次数，默认3，负数不重试
+	MinRetryBackoff    time.Duration `json:"min_retry_backoff"`    //重试最小间隔，默认8毫秒，负数不等待
+	MaxRetryBackoff    time.Duration `json:"max_retry_backoff"`    //重试最大间隔，默认512毫秒，负数不等待
+	DisableHook        bool          `json:"disable_hook"`         //关闭命令钩子（慢命令日志、指标、链路追踪），默认开启
+	SlowThreshold      time.Duration `json:"slow_threshold"`       //慢命令阈值，默认100毫秒，负数不记录
+	Trace              bool          `json:"trace"`                //输出 OpenTelemetry Span，需设置全局 TracerProvider，默认关闭
 }
 
 func Add(name string, option Option) {
+	option, err := normalize(name, option)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	store(name, option)
+}
+
+// store 保存配置，首次保存时注册到生命周期管理
+func store(name string, option Option) {
+	optionsMutex.Lock()
+	options[name] = option
+	optionsMutex.Unlock()
+
+	register.Do(func() {
+		lifecycle.Register(lifecycle.StagePool, "redis", CloseAll)
+	})
+}
+
+// normalize 校验配置并填充默认值
+func normalize(name string, option Option) (Option, error) {
 	if len(option.Address) == 0 {
-		panic("Option address array empty " + name)
+		return option, errors.New("Option address array empty " + name)
+	}
+
+	switch option.Mode {
+	case "":
+		option.Mode = ModeStandalone
+	case ModeStandalone, ModeSentinel, ModeCluster:
+	default:
+		return option, errors.New("Option mode invalid " + name + " " + option.Mode)
 	}
 
 	// 默认值
@@ -45,11 +102,49 @@ func Add(name string, option Option) {
 	if option.ConnMaxIdleTime <= 0 {
 		option.ConnMaxIdleTime = 5 * time.Minute
 	}
+	if option.SlowThreshold == 0 {
+		option.SlowThreshold = 100 * time.Millisecond
+	}
 
-	options[name] = option
+	// 证书相关配置隐含启用TLS
+	if option.TLSCertFile != "" || option.TLSKeyFile != "" || option.TLSCAFile != "" ||
+		option.TLSServerName != "" || option.InsecureSkipVerify {
+		option.TLS = true
+	}
+	if (option.TLSCertFile == "") != (option.TLSKeyFile == "") {
+		return option, errors.New("Option tls_cert_file and tls_key_file must be set together " + name)
+	}
+
+	// go-redis 以-1表示不超时、不重试、不等待，其他负数统一为-1
+	for _, duration := range []*time.Duration{
+		&option.ReadTimeout, &option.WriteTimeout, &option.MinRetryBackoff, &option.MaxRetryBackoff,
+	} {
+		if *duration < 0 {
+			*duration = -1
+		}
+	}
+	if option.MaxRetries < 0 {
+		option.MaxRetries = -1
+	}
+
+	return option, nil
+}
+
+// getOption 读取配置，可与 Reload 并发
+func getOption(name string) (Option, bool) {
+	optionsMutex.RLock()
+	defer optionsMutex.RUnlock()
+
+	option, ok := options[name]
+	return option, ok
 }
 
 func AddMap(name string, setting map[string]interface{}) {
+	Add(name, parseMap(setting))
+}
+
+// parseMap 解析 map 配置
+func parseMap(setting map[string]interface{}) Option {
 	addressStrings := make([]string, 0)
 	for _, v := range setting["address"].([]interface{}) {
 		addressStrings = append(addressStrings, v.(string))
@@ -59,6 +154,12 @@ func AddMap(name string, setting map[string]interface{}) {
 		Address: addressStrings,
 	}
 
+	if mode, ok := setting["mode"]; ok {
+		option.Mode = mode.(string)
+	}
+	if username, ok := setting["username"]; ok {
+		option.Username = username.(string)
+	}
 	if password, ok := setting["password"]; ok {
 		option.Password = password.(string)
 	}
@@ -75,13 +176,7 @@ func AddMap(name string, setting map[string]interface{}) {
 		option.MaxIdleConns = maxIdleConns.(int)
 	}
 	if connMaxIdleTime, ok := setting["conn_max_idle_time"]; ok {
-		if duration, isString := connMaxIdleTime.(string); isString {
-			if parsed, err := time.ParseDuration(duration); err == nil {
-				option.ConnMaxIdleTime = parsed
-			}
-		} else if duration, isInt := connMaxIdleTime.(int); isInt {
-			option.ConnMaxIdleTime = time.Duration(duration) * time.Second
-		}
+		option.ConnMaxIdleTime = parseDuration(connMaxIdleTime, time.Second)
 	}
 	if masterName, ok := setting["master_name"]; ok {
 		option.MasterName = masterName.(string)
@@ -89,8 +184,64 @@ func AddMap(name string, setting map[string]interface{}) {
 	if tls, ok := setting["tls"]; ok {
 		option.TLS = tls.(bool)
 	}
+	if certFile, ok := setting["tls_cert_file"]; ok {
+		option.TLSCertFile = certFile.(string)
+	}
+	if keyFile, ok := setting["tls_key_file"]; ok {
+		option.TLSKeyFile = keyFile.(string)
+	}
+	if caFile, ok := setting["tls_ca_file"]; ok {
+		option.TLSCAFile = caFile.(string)
+	}
+	if serverName, ok := setting["tls_server_name"]; ok {
+		option.TLSServerName = serverName.(string)
+	}
+	if insecureSkipVerify, ok := setting["insecure_skip_verify"]; ok {
+		option.InsecureSkipVerify = insecureSkipVerify.(bool)
+	}
+	if dialTimeout, ok := setting["dial_timeout"]; ok {
+		option.DialTimeout = parseDuration(dialTimeout, time.Second)
+	}
+	if readTimeout, ok := setting["read_timeout"]; ok {
+		option.ReadTimeout = parseDuration(readTimeout, time.Second)
+	}
+	if writeTimeout, ok := setting["write_timeout"]; ok {
+		option.WriteTimeout = parseDuration(writeTimeout, time.Second)
+	}
+	if maxRetries, ok := setting["max_retries"]; ok {
+		option.MaxRetries = maxRetries.(int)
+	}
+	if minRetryBackoff, ok := setting["min_retry_backoff"]; ok {
+		option.MinRetryBackoff = parseDuration(minRetryBackoff, time.Millisecond)
+	}
+	if maxRetryBackoff, ok := setting["max_retry_backoff"]; ok {
+		option.MaxRetryBackoff = parseDuration(maxRetryBackoff, time.Millisecond)
+	}
+	if disableHook, ok := setting["disable_hook"]; ok {
+		option.DisableHook = disableHook.(bool)
+	}
+	if slowThreshold, ok := setting["slow_threshold"]; ok {
+		option.SlowThreshold = parseDuration(slowThreshold, time.Millisecond)
+	}
+	if trace, ok := setting["trace"]; ok {
+		option.Trace = trace.(bool)
+	}
 
-	Add(name, option)
+	return option
+}
+
+// parseDuration 解析时长，字符串如"500ms"，整数按 unit 计，无法解析时为0（使用默认值）
+func parseDuration(value interface{}, unit time.Duration) time.Duration {
+	switch duration := value.(type) {
+	case string:
+		if parsed, err := time.ParseDuration(duration); err == nil {
+			return parsed
+		}
+	case int:
+		return time.Duration(duration) * unit
+	}
+
+	return 0
 }
 
 func AddMapBatch(batch map[string]interface{}) {
@@ -99,52 +250,178 @@ func AddMapBatch(batch map[string]interface{}) {
 	}
 }
 
-// Use 使用
+// Names 已添加的配置名，按名称排序
+func Names() []string {
+	optionsMutex.RLock()
+	defer optionsMutex.RUnlock()
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Universal 按配置的部署模式使用，各模式通用，留空使用default，失败时panic
+func Universal(name string) redis.UniversalClient {
+	return must(Get(Ctx, name))
+}
+
+// Get 按配置的部署模式使用，各模式通用，留空使用default
+// 不存在时新建连接，失败时返回错误且不缓存，下次调用重新连接
+func Get(ctx context.Context, name string) (redis.UniversalClient, error) {
+	if name == "" {
+		name = "default"
+	}
+
+	option, ok := getOption(name)
+	if !ok {
+		return nil, errors.New("Option not found " + name)
+	}
+
+	var client redis.UniversalClient
+	var err error
+	switch option.Mode {
+	case ModeCluster:
+		client, err = GetCluster(ctx, name)
+	case ModeSentinel:
+		client, err = GetSentinel(ctx, name)
+	default:
+		client, err = GetClient(ctx, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Use 使用，sentinel模式等同 Sentinel，cluster模式请使用 Universal 或 Cluster，失败时panic
 func Use(name string) *redis.Client {
+	return must(GetClient(Ctx, name))
+}
+
+// GetClient 使用，sentinel模式等同 GetSentinel，cluster模式返回错误
+func GetClient(ctx context.Context, name string) (*redis.Client, error) {
 	if name == "" {
 		name = "default"
 	}
 
 	if instance, ok := pool.Load(name); ok {
-		return instance.(*redis.Client)
-	} else {
-		mutex.Lock()
-		defer mutex.Unlock()
-		if instance, ok = pool.Load(name); ok {
-			return instance.(*redis.Client)
-		}
+		return instance.(*redis.Client), nil
 	}
 
-	option, ok := options[name]
+	option, ok := getOption(name)
 	if !ok {
-		panic("Option not found " + name)
+		return nil, errors.New("Option not found " + name)
+	}
+	switch option.Mode {
+	case ModeSentinel:
+		return GetSentinel(ctx, name)
+	case ModeCluster:
+		return nil, errors.New("Option mode is cluster, use Universal or Cluster " + name)
 	}
 
+	mutex.Lock()
+	defer mutex.Unlock()
+	if instance, ok := pool.Load(name); ok {
+		return instance.(*redis.Client), nil
+	}
+
+	// 加锁后重新读取，避免使用 Reload 之前的配置
+	option, ok = getOption(name)
+	if !ok {
+		return nil, errors.New("Option not found " + name)
+	}
+
+	newClient, err := dialClient(ctx, name, option)
+	if err != nil {
+		return nil, err
+	}
+
+	pool.Store(name, newClient)
+	return newClient, nil
+}
+
+// dialClient 新建单机连接并Ping，不缓存
+func dialClient(ctx context.Context, name string, option Option) (*redis.Client, error) {
 	// 默认输出到 stderr，纠正为 stdout
 	redis.SetLogger(newStdoutLogger())
 
+	tlsConfig, err := newTLSConfig(option)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect redis %s err: %w", name, err)
+	}
+
 	clientOptions := &redis.Options{
 		Addr:            option.Address[0],
 		DB:              option.DB,
+		Username:        option.Username,
 		Password:        option.Password,
 		PoolSize:        option.PoolSize,
 		MinIdleConns:    option.MinIdleConns,
 		MaxIdleConns:    option.MaxIdleConns,
 		ConnMaxIdleTime: option.ConnMaxIdleTime,
-	}
-	if option.TLS {
-		clientOptions.TLSConfig = &tls.Config{}
+		TLSConfig:       tlsConfig,
+		DialTimeout:     option.DialTimeout,
+		ReadTimeout:     option.ReadTimeout,
+		WriteTimeout:    option.WriteTimeout,
+		MaxRetries:      option.MaxRetries,
+		MinRetryBackoff: option.MinRetryBackoff,
+		MaxRetryBackoff: option.MaxRetryBackoff,
 	}
 
 	newClient := redis.NewClient(clientOptions)
+	if !option.DisableHook {
+		newClient.AddHook(NewHook(name, option))
+	}
 
-	info, err := newClient.Ping(Ctx).Result()
-	if err == nil {
-		fmt.Println("Connected to redis", name, info)
-	} else {
-		panic("Failed to connect redis " + name + " err: " + err.Error())
+	info, err := newClient.Ping(ctx).Result()
+	if err != nil {
+		_ = newClient.Close()
+		return nil, fmt.Errorf("Failed to connect redis %s err: %w", name, err)
 	}
+	fmt.Println("Connected to redis", name, info)
 
-	pool.Store(name, newClient)
-	return newClient
+	return newClient, nil
+}
+
+// Close 关闭并移除连接，包括 Cluster、Sentinel，下次使用时重新连接，留空使用default
+func Close(name string) error {
+	if name == "" {
+		name = "default"
+	}
+
+	var errs []error
+	for _, instances := range []*sync.Map{pool, poolCluster, poolSentinel} {
+		if instance, ok := instances.LoadAndDelete(name); ok {
+			errs = append(errs, instance.(io.Closer).Close())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CloseAll 关闭所有连接，ctx结束时不再等待
+func CloseAll(ctx context.Context) error {
+	closeFunc := func(instance any) error {
+		return instance.(io.Closer).Close()
+	}
+
+	return errors.Join(
+		lifecycle.ClosePool(ctx, pool, closeFunc),
+		lifecycle.ClosePool(ctx, poolCluster, closeFunc),
+		lifecycle.ClosePool(ctx, poolSentinel, closeFunc),
+	)
+}
+
+// must 失败时panic，兼容原有的使用方式
+func must[T any](client T, err error) T {
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return client
 }
@@ -1,12 +1,14 @@
 package redis
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
 )
 
 // setupMiniRedis 创建一个miniredis实例用于测试
@@ -18,6 +20,16 @@ func setupMiniRedis(t *testing.T) *miniredis.Miniredis {
 	return s
 }
 
+// setupRedis 启动 miniredis 并添加为 name 配置，测试结束时关闭
+func setupRedis(t *testing.T, name string) *miniredis.Miniredis {
+	s := setupMiniRedis(t)
+	Add(name, Option{Address: []string{s.Addr()}})
+	pool.Delete(name)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
 // TestAdd 测试添加配置
 func TestAdd(t *testing.T) {
 	option := Option{
@@ -634,3 +646,172 @@ func TestPoolSizeConfiguration(t *testing.T) {
 		})
 	}
 }
+
+// TestAddMode 测试部署模式
+func TestAddMode(t *testing.T) {
+	Add("test_mode_default", Option{Address: []string{"localhost:6379"}})
+	if options["test_mode_default"].Mode != ModeStandalone {
+		t.Errorf("期望默认为standalone，实际为%s", options["test_mode_default"].Mode)
+	}
+
+	AddMap("test_mode_map", map[string]interface{}{
+		"mode":    "cluster",
+		"address": []interface{}{"localhost:7000"},
+	})
+	if options["test_mode_map"].Mode != ModeCluster {
+		t.Errorf("期望为cluster，实际为%s", options["test_mode_map"].Mode)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("无效的模式应该panic")
+		}
+	}()
+	Add("test_mode_invalid", Option{Mode: "invalid", Address: []string{"localhost:6379"}})
+}
+
+// TestUniversal 测试通用客户端
+func TestUniversal(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+
+	Add("test_universal", Option{Address: []string{s.Addr()}})
+	pool.Delete("test_universal")
+
+	// 单机模式与 Use 为同一实例
+	client := Universal("test_universal")
+	if client != Use("test_universal") {
+		t.Error("单机模式应该与 Use 复用连接")
+	}
+	if err := client.Set(Ctx, "universal", "value", 0).Err(); err != nil {
+		t.Errorf("Set失败: %v", err)
+	}
+}
+
+// TestUniversalCluster 测试cluster模式，工具在各模式下通用
+func TestUniversalCluster(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+
+	Add("test_universal_cluster", Option{Mode: ModeCluster, Address: []string{s.Addr()}})
+	poolCluster.Delete("test_universal_cluster")
+
+	// 并发获取只创建一个实例
+	clients := make([]redis.UniversalClient, 10)
+	var wg sync.WaitGroup
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clients[i] = Universal("test_universal_cluster")
+		}(i)
+	}
+	wg.Wait()
+	for _, client := range clients {
+		if client != clients[0] {
+			t.Fatal("连接未能复用")
+		}
+	}
+	if _, ok := clients[0].(*redis.ClusterClient); !ok {
+		t.Fatalf("期望为ClusterClient，实际为%T", clients[0])
+	}
+
+	ctx := context.Background()
+	mutex := NewMutex("universal", time.Second)
+	mutex.Client = "test_universal_cluster"
+	if ok, err := mutex.TryLock(ctx); !ok || err != nil {
+		t.Errorf("加锁失败 %v %v", ok, err)
+	}
+	if err := mutex.Unlock(ctx); err != nil {
+		t.Errorf("解锁失败 %v", err)
+	}
+
+	maxMin := MaxMin{Client: "test_universal_cluster", CacheKey: "universal:", Name: "max"}
+	if value, updated, err := maxMin.UpdateMax(ctx, 10); value != 10 || !updated || err != nil {
+		t.Errorf("更新最大值失败 %d %v %v", value, updated, err)
+	}
+
+	limiter := NewFixedWindow(1, time.Minute)
+	limiter.Client = "test_universal_cluster"
+	if result, err := limiter.Allow(ctx, "universal"); !result.Allowed || err != nil {
+		t.Errorf("限流失败 %+v %v", result, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("cluster模式使用 Use 应该panic")
+		}
+	}()
+	Use("test_universal_cluster")
+}
+
+// TestGet 测试返回错误的使用方式
+func TestGet(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := Get(ctx, "test_get_not_found"); err == nil || err.Error() != "Option not found test_get_not_found" {
+		t.Errorf("配置不存在时应该返回错误，实际为 %v", err)
+	}
+
+	// 无法连接时返回错误，不缓存
+	Add("test_get_unreachable", Option{Address: []string{"127.0.0.1:1"}})
+	if _, err := Get(ctx, "test_get_unreachable"); err == nil {
+		t.Error("无法连接时应该返回错误")
+	}
+	if _, ok := pool.Load("test_get_unreachable"); ok {
+		t.Error("连接失败不应该缓存")
+	}
+
+	Add("test_get_cluster", Option{Mode: ModeCluster, Address: []string{"127.0.0.1:1"}})
+	if _, err := GetClient(ctx, "test_get_cluster"); err == nil {
+		t.Error("cluster模式使用 GetClient 应该返回错误")
+	}
+
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("test_get", Option{Address: []string{s.Addr()}})
+	pool.Delete("test_get")
+
+	client, err := Get(ctx, "test_get")
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	if client != Universal("test_get") {
+		t.Error("Get 与 Universal 应该复用连接")
+	}
+}
+
+// TestClose 测试关闭连接
+func TestClose(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+
+	Add("test_close", Option{Address: []string{s.Addr()}})
+	pool.Delete("test_close")
+
+	client := Use("test_close")
+	if err := Close("test_close"); err != nil {
+		t.Errorf("关闭失败: %v", err)
+	}
+	if err := client.Ping(Ctx).Err(); err == nil {
+		t.Error("关闭后不应该可用")
+	}
+
+	// 关闭后重新连接
+	reconnected := Use("test_close")
+	if reconnected == client {
+		t.Error("关闭后应该重新连接")
+	}
+
+	Add("test_close_cluster", Option{Mode: ModeCluster, Address: []string{s.Addr()}})
+	Universal("test_close_cluster")
+	if err := CloseAll(context.Background()); err != nil {
+		t.Errorf("关闭所有连接失败: %v", err)
+	}
+	if _, ok := pool.Load("test_close"); ok {
+		t.Error("连接未移除")
+	}
+	if _, ok := poolCluster.Load("test_close_cluster"); ok {
+		t.Error("集群连接未移除")
+	}
+}
@@ -1,7 +1,8 @@
 package redis
 
 import (
-	"crypto/tls"
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -13,48 +14,76 @@ var (
 	mutexSentinel sync.Mutex    //互斥锁
 )
 
-// Sentinel 使用Sentinel集群
+// Sentinel 使用Sentinel集群，失败时panic
 func Sentinel(name string) *redis.Client {
+	return must(GetSentinel(Ctx, name))
+}
+
+// GetSentinel 使用Sentinel集群，不存在时新建连接，失败时返回错误且不缓存
+func GetSentinel(ctx context.Context, name string) (*redis.Client, error) {
+	if instance, ok := poolSentinel.Load(name); ok {
+		return instance.(*redis.Client), nil
+	}
+
+	mutexSentinel.Lock()
+	defer mutexSentinel.Unlock()
 	if instance, ok := poolSentinel.Load(name); ok {
-		return instance.(*redis.Client)
-	} else {
-		mutexSentinel.Lock()
-		defer mutexSentinel.Unlock()
-		if instance, ok = pool.Load(name); ok {
-			return instance.(*redis.Client)
-		}
+		return instance.(*redis.Client), nil
 	}
 
-	option, ok := options[name]
+	option, ok := getOption(name)
 	if !ok {
-		panic("Option not found " + name)
+		return nil, errors.New("Option not found " + name)
+	}
+
+	newClient, err := dialSentinel(ctx, name, option)
+	if err != nil {
+		return nil, err
 	}
 
+	poolSentinel.Store(name, newClient)
+	return newClient, nil
+}
+
+// dialSentinel 新建连接并Ping，不缓存
+func dialSentinel(ctx context.Context, name string, option Option) (*redis.Client, error) {
 	// 默认输出到 stderr，纠正为 stdout
 	redis.SetLogger(newStdoutLogger())
 
+	tlsConfig, err := newTLSConfig(option)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect redis sentinel %s err: %w", name, err)
+	}
+
 	failoverOptions := &redis.FailoverOptions{
 		MasterName:      option.MasterName,
 		SentinelAddrs:   option.Address,
+		Username:        option.Username,
 		Password:        option.Password,
 		PoolSize:        option.PoolSize,
 		MinIdleConns:    option.MinIdleConns,
 		MaxIdleConns:    option.MaxIdleConns,
 		ConnMaxIdleTime: option.ConnMaxIdleTime,
-	}
-	if option.TLS {
-		failoverOptions.TLSConfig = &tls.Config{}
+		TLSConfig:       tlsConfig,
+		DialTimeout:     option.DialTimeout,
+		ReadTimeout:     option.ReadTimeout,
+		WriteTimeout:    option.WriteTimeout,
+		MaxRetries:      option.MaxRetries,
+		MinRetryBackoff: option.MinRetryBackoff,
+		MaxRetryBackoff: option.MaxRetryBackoff,
 	}
 
 	newClient := redis.NewFailoverClient(failoverOptions)
+	if !option.DisableHook {
+		newClient.AddHook(NewHook(name, option))
+	}
 
-	info, err := newClient.Ping(Ctx).Result()
-	if err == nil {
-		fmt.Println("Connected to redis sentinel", name, info)
-	} else {
-		panic("Failed to connect redis sentinel " + name + " err: " + err.Error())
+	info, err := newClient.Ping(ctx).Result()
+	if err != nil {
+		_ = newClient.Close()
+		return nil, fmt.Errorf("Failed to connect redis sentinel %s err: %w", name, err)
 	}
+	fmt.Println("Connected to redis sentinel", name, info)
 
-	poolSentinel.Store(name, newClient)
-	return newClient
+	return newClient, nil
 }
@@ -0,0 +1,257 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	mathRand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redlock 多节点分布式锁，在多个独立主库上获得多数派才算加锁成功
+// 参考 https://redis.io/docs/latest/develop/use/patterns/distributed-locks/
+type Redlock struct {
+	Name        string        // 锁名称
+	Clients     []string      // redis配置名称，各节点应为相互独立的主库
+	Expire      time.Duration // 过期时间，默认30秒
+	DriftFactor float64       // 时钟漂移系数，默认0.01，有效期扣除 Expire*DriftFactor+2毫秒
+	Timeout     time.Duration // 单节点超时，默认 Expire/10，避免在故障节点上阻塞过久
+	RetryMin    time.Duration // 阻塞加锁的最小重试间隔，默认50毫秒，之后指数退避
+	RetryMax    time.Duration // 阻塞加锁的最大重试间隔，默认1秒
+
+	token string
+	until time.Time
+	mutex sync.Mutex
+}
+
+// NewRedlock 多节点分布式锁实例化
+func NewRedlock(name string, expire time.Duration, clients ...string) *Redlock {
+	return &Redlock{Name: name, Expire: expire, Clients: clients}
+}
+
+// TryLock 尝试加锁，不阻塞
+// 多数派节点加锁成功且扣除耗时与时钟漂移后仍在有效期内才算成功，否则释放已加锁的节点
+// 未成功时，error 为各节点的错误，节点均正常但锁被他人持有时为 nil
+func (r *Redlock) TryLock(ctx context.Context) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.token != "" {
+		return false, nil
+	}
+
+	token := newToken()
+	start := time.Now()
+	acquired, errs := r.each(ctx, func(ctx context.Context, client redis.UniversalClient) (bool, error) {
+		return client.SetNX(ctx, r.key(), token, r.expire()).Result()
+	})
+
+	validity := r.expire() - time.Since(start) - r.drift()
+	if acquired >= r.quorum() && validity > 0 {
+		r.token = token
+		r.until = start.Add(r.expire() - r.drift())
+		return true, nil
+	}
+
+	// 未获得多数派，释放所有节点，包括超时但可能已写入的节点
+	r.unlockAll(token)
+
+	return false, errors.Join(errs...)
+}
+
+// Lock 阻塞加锁，直至成功或ctx结束
+// 部分节点故障时继续重试，直至故障节点恢复或ctx结束
+func (r *Redlock) Lock(ctx context.Context) error {
+	retryMin, retryMax := r.RetryMin, r.RetryMax
+	if retryMin <= 0 {
+		retryMin = 50 * time.Millisecond
+	}
+	if retryMax < retryMin {
+		retryMax = max(time.Second, retryMin)
+	}
+
+	interval := retryMin
+	for {
+		ok, err := r.TryLock(ctx)
+		if ok {
+			return nil
+		}
+
+		// 随机等待，避免多个客户端同时重试导致都无法获得多数派
+		wait := interval/2 + time.Duration(mathRand.Int63n(int64(interval/2)+1))
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("%w: %w: %w", ErrLockFailed, ctx.Err(), err)
+			}
+			return fmt.Errorf("%w: %w", ErrLockFailed, ctx.Err())
+		case <-time.After(wait):
+		}
+
+		interval = min(interval*2, retryMax)
+	}
+}
+
+// Unlock 解锁，所有节点都会尝试释放
+// 释放成功的节点未达到多数派时返回 ErrLockNotHeld，说明锁在解锁前已失效
+func (r *Redlock) Unlock(ctx context.Context) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.token == "" {
+		return ErrLockNotHeld
+	}
+
+	token := r.token
+	r.token, r.until = "", time.Time{}
+
+	released, errs := r.each(ctx, func(ctx context.Context, client redis.UniversalClient) (bool, error) {
+		result, err := scriptUnlock.Run(ctx, client, []string{r.key()}, token).Int64()
+		return result == 1, err
+	})
+	if released < r.quorum() {
+		return errors.Join(append([]error{ErrLockNotHeld}, errs...)...)
+	}
+
+	return nil
+}
+
+// Refresh 续期，续期成功的节点未达到多数派时返回 ErrLockNotHeld 并清除持有状态
+func (r *Redlock) Refresh(ctx context.Context) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.token == "" {
+		return ErrLockNotHeld
+	}
+
+	token := r.token
+	start := time.Now()
+	refreshed, errs := r.each(ctx, func(ctx context.Context, client redis.UniversalClient) (bool, error) {
+		result, err := scriptRefresh.Run(ctx, client, []string{r.key()}, token, r.expire().Milliseconds()).Int64()
+		return result == 1, err
+	})
+
+	validity := r.expire() - time.Since(start) - r.drift()
+	if refreshed < r.quorum() || validity <= 0 {
+		r.token, r.until = "", time.Time{}
+		r.unlockAll(token)
+		return errors.Join(append([]error{ErrLockNotHeld}, errs...)...)
+	}
+
+	r.until = start.Add(r.expire() - r.drift())
+	return nil
+}
+
+// Validity 剩余有效期，已扣除时钟漂移，未持有或已过期时为0
+// 业务执行时间超过有效期时，锁可能已被他人获得
+func (r *Redlock) Validity() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.token == "" {
+		return 0
+	}
+
+	return max(time.Until(r.until), 0)
+}
+
+// Token 持有者令牌，未持有时为空
+func (r *Redlock) Token() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.token
+}
+
+// each 并发在所有节点上执行，返回成功节点数与错误
+func (r *Redlock) each(ctx context.Context, fn func(ctx context.Context, client redis.UniversalClient) (bool, error)) (int, []error) {
+	var (
+		wait  sync.WaitGroup
+		mutex sync.Mutex
+		count int
+		errs  []error
+	)
+
+	for _, name := range r.Clients {
+		wait.Add(1)
+		go func(name string) {
+			defer wait.Done()
+
+			nodeCtx, cancel := context.WithTimeout(ctx, r.timeout())
+			defer cancel()
+
+			ok, err := r.call(nodeCtx, name, fn)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("redlock %s: %w", name, err))
+			} else if ok {
+				count++
+			}
+		}(name)
+	}
+	wait.Wait()
+
+	return count, errs
+}
+
+// call 单节点执行，节点首次连接失败时 Use 会 panic，转为错误以便其他节点继续
+func (r *Redlock) call(ctx context.Context, name string, fn func(ctx context.Context, client redis.UniversalClient) (bool, error)) (ok bool, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%v", recovered)
+		}
+	}()
+
+	return fn(ctx, Universal(name))
+}
+
+// unlockAll 释放所有节点，忽略错误
+func (r *Redlock) unlockAll(token string) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout())
+	defer cancel()
+
+	r.each(ctx, func(ctx context.Context, client redis.UniversalClient) (bool, error) {
+		return true, scriptUnlock.Run(ctx, client, []string{r.key()}, token).Err()
+	})
+}
+
+// quorum 多数派节点数
+func (r *Redlock) quorum() int {
+	return len(r.Clients)/2 + 1
+}
+
+// drift 时钟漂移补偿
+func (r *Redlock) drift() time.Duration {
+	factor := r.DriftFactor
+	if factor <= 0 {
+		factor = 0.01
+	}
+
+	return time.Duration(float64(r.expire())*factor) + 2*time.Millisecond
+}
+
+func (r *Redlock) timeout() time.Duration {
+	if r.Timeout <= 0 {
+		return r.expire() / 10
+	}
+
+	return r.Timeout
+}
+
+func (r *Redlock) key() string {
+	return NamespaceKey(KeyLock + r.Name)
+}
+
+func (r *Redlock) expire() time.Duration {
+	if r.Expire <= 0 {
+		return 30 * time.Second
+	}
+
+	return r.Expire
+}
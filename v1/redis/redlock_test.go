@@ -0,0 +1,188 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// setupRedlock 启动多个独立节点
+func setupRedlock(t *testing.T, prefix string, count int) ([]*miniredis.Miniredis, []string) {
+	servers := make([]*miniredis.Miniredis, 0, count)
+	names := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		s := setupMiniRedis(t)
+		name := prefix + "_" + strconv.Itoa(i)
+		Add(name, Option{Address: []string{s.Addr()}})
+		pool.Delete(name)
+
+		servers = append(servers, s)
+		names = append(names, name)
+	}
+
+	t.Cleanup(func() {
+		for _, s := range servers {
+			s.Close()
+		}
+	})
+
+	return servers, names
+}
+
+// TestRedlock 测试所有节点正常时加锁解锁
+func TestRedlock(t *testing.T) {
+	servers, names := setupRedlock(t, "redlock_all", 3)
+	ctx := context.Background()
+
+	lock := NewRedlock("job", 10*time.Second, names...)
+	if ok, err := lock.TryLock(ctx); !ok || err != nil {
+		t.Fatalf("加锁失败: %v", err)
+	}
+
+	for i, s := range servers {
+		if value, _ := s.Get(KeyLock + "job"); value != lock.Token() {
+			t.Errorf("节点%d期望值为%s，实际为%s", i, lock.Token(), value)
+		}
+		if ttl := s.TTL(KeyLock + "job"); ttl != 10*time.Second {
+			t.Errorf("节点%d期望过期时间为10秒，实际为%v", i, ttl)
+		}
+	}
+
+	// 有效期扣除时钟漂移
+	if validity := lock.Validity(); validity <= 0 || validity > 10*time.Second-100*time.Millisecond {
+		t.Errorf("有效期应扣除时钟漂移，实际为%v", validity)
+	}
+
+	other := NewRedlock("job", 10*time.Second, names...)
+	if ok, err := other.TryLock(ctx); ok || err != nil {
+		t.Errorf("锁已被持有，期望加锁失败且无错误，实际为%v %v", ok, err)
+	}
+
+	if err := lock.Refresh(ctx); err != nil {
+		t.Errorf("续期失败: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Errorf("解锁失败: %v", err)
+	}
+	for i, s := range servers {
+		if s.Exists(KeyLock + "job") {
+			t.Errorf("节点%d解锁后锁应该不存在", i)
+		}
+	}
+	if lock.Validity() != 0 {
+		t.Error("解锁后有效期应该为0")
+	}
+}
+
+// TestRedlockMinorityFailure 测试少数节点故障时仍可加锁
+func TestRedlockMinorityFailure(t *testing.T) {
+	servers, names := setupRedlock(t, "redlock_minority", 3)
+	ctx := context.Background()
+
+	// 一个节点故障，且从未连接成功
+	servers[2].Close()
+
+	lock := &Redlock{Name: "minority", Clients: names, Expire: 10 * time.Second, Timeout: 200 * time.Millisecond}
+	if ok, err := lock.TryLock(ctx); !ok || err != nil {
+		t.Fatalf("多数派节点正常时应该加锁成功: %v", err)
+	}
+	if !servers[0].Exists(KeyLock+"minority") || !servers[1].Exists(KeyLock+"minority") {
+		t.Error("正常节点应该已加锁")
+	}
+
+	if err := lock.Unlock(ctx); err != nil {
+		t.Errorf("多数派节点解锁成功时不应该返回错误: %v", err)
+	}
+}
+
+// TestRedlockMajorityFailure 测试多数节点故障时加锁失败，并回滚已加锁的节点
+func TestRedlockMajorityFailure(t *testing.T) {
+	servers, names := setupRedlock(t, "redlock_majority", 3)
+	ctx := context.Background()
+
+	// 先建立连接，再让两个节点故障
+	for _, name := range names {
+		Use(name)
+	}
+	servers[1].Close()
+	servers[2].Close()
+
+	lock := &Redlock{Name: "majority", Clients: names, Expire: 10 * time.Second, Timeout: 200 * time.Millisecond}
+	ok, err := lock.TryLock(ctx)
+	if ok {
+		t.Fatal("多数节点故障时不应该加锁成功")
+	}
+	if err == nil {
+		t.Error("期望返回故障节点的错误")
+	}
+	if servers[0].Exists(KeyLock + "majority") {
+		t.Error("未获得多数派时应该释放已加锁的节点")
+	}
+
+	// 阻塞加锁超时
+	timeoutCtx, cancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer cancel()
+	if err := lock.Lock(timeoutCtx); !errors.Is(err, ErrLockFailed) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望ErrLockFailed，实际为%v", err)
+	}
+}
+
+// TestRedlockContention 测试多数节点被他人持有时加锁失败，且不影响他人的锁
+func TestRedlockContention(t *testing.T) {
+	servers, names := setupRedlock(t, "redlock_contention", 3)
+	ctx := context.Background()
+
+	servers[0].Set(KeyLock+"contention", "other")
+	servers[1].Set(KeyLock+"contention", "other")
+
+	lock := NewRedlock("contention", 10*time.Second, names...)
+	if ok, err := lock.TryLock(ctx); ok || err != nil {
+		t.Fatalf("期望加锁失败且无错误，实际为%v %v", ok, err)
+	}
+	if servers[2].Exists(KeyLock + "contention") {
+		t.Error("未获得多数派时应该释放已加锁的节点")
+	}
+	if value, _ := servers[0].Get(KeyLock + "contention"); value != "other" {
+		t.Error("不应该释放他人的锁")
+	}
+
+	// 他人释放后阻塞加锁成功
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		servers[0].Del(KeyLock + "contention")
+	}()
+	lock.RetryMin, lock.RetryMax = 10*time.Millisecond, 20*time.Millisecond
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := lock.Lock(timeoutCtx); err != nil {
+		t.Errorf("他人释放后应该加锁成功: %v", err)
+	}
+}
+
+// TestRedlockRefreshExpired 测试锁在多数节点过期后续期失败
+func TestRedlockRefreshExpired(t *testing.T) {
+	servers, names := setupRedlock(t, "redlock_refresh", 3)
+	ctx := context.Background()
+
+	lock := NewRedlock("refresh", 10*time.Second, names...)
+	if ok, _ := lock.TryLock(ctx); !ok {
+		t.Fatal("加锁失败")
+	}
+
+	servers[0].FastForward(11 * time.Second)
+	servers[1].FastForward(11 * time.Second)
+
+	if err := lock.Refresh(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("期望ErrLockNotHeld，实际为%v", err)
+	}
+	if lock.Token() != "" {
+		t.Error("续期失败后应该清除持有状态")
+	}
+	if servers[2].Exists(KeyLock + "refresh") {
+		t.Error("续期失败后应该释放剩余节点")
+	}
+}
@@ -0,0 +1,157 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type lockOwnerKey struct{}
+
+var (
+	// 无人持有或自己持有时计数加一，返回持有次数，他人持有返回0
+	scriptReentrantLock = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 or redis.call("HEXISTS", KEYS[1], ARGV[1]) == 1 then
+	local count = redis.call("HINCRBY", KEYS[1], ARGV[1], 1)
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return count
+end
+return 0`)
+
+	// 计数减一，归零时删除，返回剩余次数，未持有返回-1
+	scriptReentrantUnlock = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], ARGV[1]) == 0 then
+	return -1
+end
+local count = redis.call("HINCRBY", KEYS[1], ARGV[1], -1)
+if count <= 0 then
+	redis.call("DEL", KEYS[1])
+	return 0
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+return count`)
+
+	// 自己持有时续期
+	scriptReentrantRefresh = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], ARGV[1]) == 1 then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
+// WithLockOwner 在ctx中指定锁的持有者，嵌套调用传递同一ctx即可重入
+func WithLockOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, lockOwnerKey{}, owner)
+}
+
+// ReentrantMutex 可重入分布式锁，同一持有者可多次加锁，解锁相同次数后释放
+// 持有者优先取ctx中 WithLockOwner 指定的值，其次为 Owner，均未指定时返回 ErrLockOwner
+type ReentrantMutex struct {
+	Name     string        // 锁名称
+	Client   string        // redis配置名称，留空使用default
+	Owner    string        // 持有者，NewReentrantMutex 默认随机生成
+	Expire   time.Duration // 过期时间，默认30秒，每次加锁、解锁都会重置
+	RetryMin time.Duration // 阻塞加锁的最小重试间隔，默认50毫秒，之后指数退避
+	RetryMax time.Duration // 阻塞加锁的最大重试间隔，默认1秒
+}
+
+// NewReentrantMutex 可重入分布式锁实例化
+func NewReentrantMutex(name string, expire time.Duration) *ReentrantMutex {
+	return &ReentrantMutex{Name: name, Expire: expire, Owner: newToken()}
+}
+
+// TryLock 尝试加锁，不阻塞
+func (m *ReentrantMutex) TryLock(ctx context.Context) (bool, error) {
+	owner, err := m.owner(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := scriptReentrantLock.Run(ctx, Universal(m.Client), []string{m.key()}, owner, m.expire().Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// Lock 阻塞加锁，直至成功或ctx结束
+func (m *ReentrantMutex) Lock(ctx context.Context) error {
+	return acquire(ctx, m.RetryMin, m.RetryMax, m.TryLock)
+}
+
+// Unlock 解锁一次，未持有时返回 ErrLockNotHeld
+func (m *ReentrantMutex) Unlock(ctx context.Context) error {
+	owner, err := m.owner(ctx)
+	if err != nil {
+		return err
+	}
+
+	count, err := scriptReentrantUnlock.Run(ctx, Universal(m.Client), []string{m.key()}, owner, m.expire().Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if count < 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// Refresh 续期，未持有时返回 ErrLockNotHeld
+func (m *ReentrantMutex) Refresh(ctx context.Context) error {
+	owner, err := m.owner(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := scriptReentrantRefresh.Run(ctx, Universal(m.Client), []string{m.key()}, owner, m.expire().Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if result == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// HoldCount 当前持有者的加锁次数，未持有时为0
+func (m *ReentrantMutex) HoldCount(ctx context.Context) (int, error) {
+	owner, err := m.owner(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := Universal(m.Client).HGet(ctx, m.key(), owner).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	return count, err
+}
+
+// owner 持有者，ctx 与 Owner 均未指定时返回 ErrLockOwner
+func (m *ReentrantMutex) owner(ctx context.Context) (string, error) {
+	if owner, ok := ctx.Value(lockOwnerKey{}).(string); ok && owner != "" {
+		return owner, nil
+	}
+	if m.Owner == "" {
+		return "", ErrLockOwner
+	}
+
+	return m.Owner, nil
+}
+
+func (m *ReentrantMutex) key() string {
+	return NamespaceKey(KeyLock + "reentrant:" + m.Name)
+}
+
+func (m *ReentrantMutex) expire() time.Duration {
+	if m.Expire <= 0 {
+		return 30 * time.Second
+	}
+
+	return m.Expire
+}
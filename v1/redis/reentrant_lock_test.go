@@ -0,0 +1,130 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestReentrantMutex 测试同一持有者重入
+func TestReentrantMutex(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("reentrant", Option{Address: []string{s.Addr()}})
+	pool.Delete("reentrant")
+
+	ctx := context.Background()
+	m := NewReentrantMutex("order", 10*time.Second)
+	m.Client = "reentrant"
+	other := &ReentrantMutex{Name: "order", Client: "reentrant", Owner: "other"}
+
+	// 外层加锁，嵌套调用再次加锁
+	if err := m.Lock(ctx); err != nil {
+		t.Fatalf("加锁失败: %v", err)
+	}
+	if ok, err := m.TryLock(ctx); !ok || err != nil {
+		t.Fatalf("重入失败: %v", err)
+	}
+	if count, _ := m.HoldCount(ctx); count != 2 {
+		t.Errorf("期望持有2次，实际为%d", count)
+	}
+	if ttl := s.TTL(KeyLock + "reentrant:order"); ttl != 10*time.Second {
+		t.Errorf("期望过期时间为10秒，实际为%v", ttl)
+	}
+
+	if ok, _ := other.TryLock(ctx); ok {
+		t.Error("他人不应该加锁成功")
+	}
+	if err := other.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("期望ErrLockNotHeld，实际为%v", err)
+	}
+
+	// 解锁一次仍持有
+	if err := m.Unlock(ctx); err != nil {
+		t.Fatalf("解锁失败: %v", err)
+	}
+	if ok, _ := other.TryLock(ctx); ok {
+		t.Error("未完全解锁时他人不应该加锁成功")
+	}
+
+	// 解锁相同次数后释放
+	if err := m.Unlock(ctx); err != nil {
+		t.Fatalf("解锁失败: %v", err)
+	}
+	if s.Exists(KeyLock + "reentrant:order") {
+		t.Error("完全解锁后锁应该不存在")
+	}
+	if err := m.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("期望ErrLockNotHeld，实际为%v", err)
+	}
+	if ok, _ := other.TryLock(ctx); !ok {
+		t.Error("释放后他人应该加锁成功")
+	}
+}
+
+// TestReentrantMutexContextOwner 测试通过ctx传递持有者
+func TestReentrantMutexContextOwner(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("reentrant_ctx", Option{Address: []string{s.Addr()}})
+	pool.Delete("reentrant_ctx")
+
+	ctx := WithLockOwner(context.Background(), "request-1")
+	outer := NewReentrantMutex("stock", 10*time.Second)
+	outer.Client = "reentrant_ctx"
+	inner := NewReentrantMutex("stock", 10*time.Second)
+	inner.Client = "reentrant_ctx"
+
+	if ok, _ := outer.TryLock(ctx); !ok {
+		t.Fatal("外层加锁失败")
+	}
+	if ok, _ := inner.TryLock(ctx); !ok {
+		t.Error("相同ctx持有者应该可以重入")
+	}
+	if ok, _ := inner.TryLock(context.Background()); ok {
+		t.Error("不同持有者不应该加锁成功")
+	}
+
+	if err := outer.Refresh(ctx); err != nil {
+		t.Errorf("续期失败: %v", err)
+	}
+
+	// 阻塞加锁超时
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := inner.Lock(timeoutCtx); !errors.Is(err, ErrLockFailed) {
+		t.Errorf("期望ErrLockFailed，实际为%v", err)
+	}
+}
+
+// TestReentrantMutexOwnerEmpty 测试未指定持有者时返回错误，不panic
+func TestReentrantMutexOwnerEmpty(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("reentrant_owner", Option{Address: []string{s.Addr()}})
+	pool.Delete("reentrant_owner")
+
+	ctx := context.Background()
+	m := &ReentrantMutex{Name: "stock", Client: "reentrant_owner"}
+	if _, err := m.TryLock(ctx); !errors.Is(err, ErrLockOwner) {
+		t.Errorf("期望ErrLockOwner，实际为%v", err)
+	}
+	if err := m.Lock(ctx); !errors.Is(err, ErrLockOwner) {
+		t.Errorf("期望ErrLockOwner，实际为%v", err)
+	}
+	if err := m.Unlock(ctx); !errors.Is(err, ErrLockOwner) {
+		t.Errorf("期望ErrLockOwner，实际为%v", err)
+	}
+	if err := m.Refresh(ctx); !errors.Is(err, ErrLockOwner) {
+		t.Errorf("期望ErrLockOwner，实际为%v", err)
+	}
+	if _, err := m.HoldCount(ctx); !errors.Is(err, ErrLockOwner) {
+		t.Errorf("期望ErrLockOwner，实际为%v", err)
+	}
+
+	// ctx 指定持有者时可用
+	if ok, err := m.TryLock(WithLockOwner(ctx, "request-1")); !ok || err != nil {
+		t.Errorf("ctx指定持有者应该加锁成功 %v", err)
+	}
+}
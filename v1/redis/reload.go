@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DrainTimeout 重新加载后，旧连接等待处理中命令完成的最长时间，超时后强制关闭
+var DrainTimeout = 30 * time.Second
+
+// Reload 重新加载配置，留空使用default，配置未变化时忽略，不存在时等同 Add
+// 已连接的先按新配置新建连接并替换，旧连接在后台等待处理中的命令完成后关闭
+// 新建失败时保留原配置与连接；部署模式变化时移除已有连接，下次使用时按新模式连接
+func Reload(ctx context.Context, name string, option Option) error {
+	if name == "" {
+		name = "default"
+	}
+
+	option, err := normalize(name, option)
+	if err != nil {
+		return err
+	}
+
+	// 与新建连接互斥，避免按旧配置新建并缓存
+	mutex.Lock()
+	defer mutex.Unlock()
+	mutexCluster.Lock()
+	defer mutexCluster.Unlock()
+	mutexSentinel.Lock()
+	defer mutexSentinel.Unlock()
+
+	old, ok := getOption(name)
+	if ok && reflect.DeepEqual(old, option) {
+		return nil
+	}
+
+	dials := []struct {
+		instances *sync.Map
+		dial      func() (redis.UniversalClient, error)
+	}{
+		{pool, func() (redis.UniversalClient, error) { return dialClient(ctx, name, option) }},
+		{poolCluster, func() (redis.UniversalClient, error) { return dialCluster(ctx, name, option) }},
+		{poolSentinel, func() (redis.UniversalClient, error) { return dialSentinel(ctx, name, option) }},
+	}
+
+	replaced := make(map[*sync.Map]redis.UniversalClient)
+	if ok && old.Mode == option.Mode {
+		for _, item := range dials {
+			if _, cached := item.instances.Load(name); !cached {
+				continue
+			}
+
+			client, err := item.dial()
+			if err != nil {
+				for _, client := range replaced {
+					_ = client.Close()
+				}
+				return err
+			}
+			replaced[item.instances] = client
+		}
+	}
+
+	store(name, option)
+	for _, item := range dials {
+		var previous any
+		var loaded bool
+		if client, ok := replaced[item.instances]; ok {
+			previous, loaded = item.instances.Swap(name, client)
+		} else {
+			previous, loaded = item.instances.LoadAndDelete(name)
+		}
+		if loaded {
+			go drain(previous.(redis.UniversalClient))
+		}
+	}
+	fmt.Println("Reloaded redis", name)
+
+	return nil
+}
+
+// ReloadMap 便捷方法，使用 map
+func ReloadMap(ctx context.Context, name string, setting map[string]interface{}) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("Option invalid %s: %v", name, recovered)
+		}
+	}()
+
+	return Reload(ctx, name, parseMap(setting))
+}
+
+// ReloadMapBatch 便捷方法，使用 map 批量重新加载，单个失败不影响其他，配置中已删除的不处理
+func ReloadMapBatch(ctx context.Context, batch map[string]interface{}) error {
+	var errs []error
+	for name, setting := range batch {
+		setting, ok := setting.(map[string]interface{})
+		if !ok {
+			errs = append(errs, errors.New("Option invalid "+name))
+			continue
+		}
+		if err := ReloadMap(ctx, name, setting); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// drain 等待处理中的命令完成后关闭旧连接，超过 DrainTimeout 强制关闭
+func drain(client redis.UniversalClient) {
+	deadline := time.Now().Add(DrainTimeout)
+	for time.Now().Before(deadline) {
+		if stats := client.PoolStats(); stats.TotalConns <= stats.IdleConns {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	_ = client.Close()
+}
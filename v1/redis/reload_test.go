@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestReload 测试地址变化时替换连接，旧连接处理完后关闭
+func TestReload(t *testing.T) {
+	s1 := setupMiniRedis(t)
+	s2 := setupMiniRedis(t)
+	ctx := context.Background()
+	DrainTimeout = time.Second
+	t.Cleanup(func() {
+		DrainTimeout = 30 * time.Second
+		Close("test_reload")
+	})
+
+	Add("test_reload", Option{Address: []string{s1.Addr()}})
+	old := Universal("test_reload")
+	old.Set(ctx, "server", "1", 0)
+	s2.Set("server", "2")
+
+	// 配置未变化时不替换
+	if err := Reload(ctx, "test_reload", Option{Address: []string{s1.Addr()}}); err != nil {
+		t.Fatalf("重新加载失败: %v", err)
+	}
+	if Universal("test_reload") != old {
+		t.Error("配置未变化时不应该替换连接")
+	}
+
+	err := ReloadMap(ctx, "test_reload", map[string]interface{}{
+		"address":   []interface{}{s2.Addr()},
+		"pool_size": 10,
+	})
+	if err != nil {
+		t.Fatalf("重新加载失败: %v", err)
+	}
+	if options["test_reload"].PoolSize != 10 {
+		t.Error("配置未更新")
+	}
+	if value, _ := Universal("test_reload").Get(ctx, "server").Result(); value != "2" {
+		t.Errorf("期望使用新地址，实际读取到%s", value)
+	}
+
+	// 旧连接空闲后关闭
+	waitUntil(t, func() bool {
+		return old.Ping(ctx).Err() != nil
+	}, "旧连接未关闭")
+}
+
+// TestReloadFailed 测试新建失败时保留原配置与连接
+func TestReloadFailed(t *testing.T) {
+	s := setupMiniRedis(t)
+	ctx := context.Background()
+	t.Cleanup(func() { Close("test_reload_failed") })
+
+	Add("test_reload_failed", Option{Address: []string{s.Addr()}})
+	old := Universal("test_reload_failed")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := Reload(timeoutCtx, "test_reload_failed", Option{Address: []string{"127.0.0.1:1"}}); err == nil {
+		t.Error("无法连接时应该返回错误")
+	}
+	if options["test_reload_failed"].Address[0] != s.Addr() || Universal("test_reload_failed") != old {
+		t.Error("失败时应该保留原配置与连接")
+	}
+	if err := old.Ping(ctx).Err(); err != nil {
+		t.Errorf("原连接不应该关闭: %v", err)
+	}
+
+	if err := Reload(ctx, "test_reload_failed", Option{Address: []string{s.Addr()}, Mode: "invalid"}); err == nil {
+		t.Error("配置无效时应该返回错误")
+	}
+	if err := ReloadMapBatch(ctx, map[string]interface{}{"test_reload_failed": map[string]interface{}{}}); err == nil {
+		t.Error("缺少地址时应该返回错误")
+	}
+}
+
+// TestReloadMode 测试部署模式变化时移除已有连接
+func TestReloadMode(t *testing.T) {
+	s := setupMiniRedis(t)
+	ctx := context.Background()
+	t.Cleanup(func() { Close("test_reload_mode") })
+
+	Add("test_reload_mode", Option{Address: []string{s.Addr()}})
+	Universal("test_reload_mode")
+
+	if err := Reload(ctx, "test_reload_mode", Option{Address: []string{s.Addr()}, Mode: ModeCluster}); err != nil {
+		t.Fatalf("重新加载失败: %v", err)
+	}
+	if _, ok := pool.Load("test_reload_mode"); ok {
+		t.Error("部署模式变化时应该移除已有连接")
+	}
+	Universal("test_reload_mode")
+	if _, ok := poolCluster.Load("test_reload_mode"); !ok {
+		t.Error("期望按新模式连接")
+	}
+}
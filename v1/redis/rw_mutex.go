@@ -0,0 +1,209 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// 无写锁时加读锁，读者记录在有序集合，score为过期时间（毫秒）
+	// KEYS[1] 写锁，KEYS[2] 读者；ARGV[1] 令牌，ARGV[2] 过期时间
+	scriptReadLock = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call("ZREMRANGEBYSCORE", KEYS[2], "-inf", now)
+redis.call("ZADD", KEYS[2], now + tonumber(ARGV[2]), ARGV[1])
+if redis.call("PTTL", KEYS[2]) < tonumber(ARGV[2]) then
+	redis.call("PEXPIRE", KEYS[2], ARGV[2])
+end
+return 1`)
+
+	// 读者续期
+	scriptReadRefresh = redis.NewScript(`
+if not redis.call("ZSCORE", KEYS[2], ARGV[1]) then
+	return 0
+end
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call("ZADD", KEYS[2], now + tonumber(ARGV[2]), ARGV[1])
+if redis.call("PTTL", KEYS[2]) < tonumber(ARGV[2]) then
+	redis.call("PEXPIRE", KEYS[2], ARGV[2])
+end
+return 1`)
+
+	// 无写锁且无有效读者时加写锁，过期读者先清理
+	scriptWriteLock = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call("ZREMRANGEBYSCORE", KEYS[2], "-inf", now)
+if redis.call("ZCARD", KEYS[2]) > 0 then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1`)
+)
+
+// RWMutex 分布式读写锁，允许多个读者或一个写者
+// 读者各自过期，崩溃的读者不会永久阻塞写者；读者持续不断时写者可能长时间等待
+type RWMutex struct {
+	Name     string        // 锁名称
+	Client   string        // redis配置名称，留空使用default
+	Expire   time.Duration // 过期时间，默认30秒
+	RetryMin time.Duration // 阻塞加锁的最小重试间隔，默认50毫秒，之后指数退避
+	RetryMax time.Duration // 阻塞加锁的最大重试间隔，默认1秒
+
+	token   string
+	writing bool
+	mutex   sync.Mutex
+}
+
+// NewRWMutex 分布式读写锁实例化，每个持有者使用独立实例
+func NewRWMutex(name string, expire time.Duration) *RWMutex {
+	return &RWMutex{Name: name, Expire: expire}
+}
+
+// TryRLock 尝试加读锁，不阻塞
+func (m *RWMutex) TryRLock(ctx context.Context) (bool, error) {
+	return m.try(ctx, scriptReadLock, false)
+}
+
+// RLock 阻塞加读锁，直至成功或ctx结束
+func (m *RWMutex) RLock(ctx context.Context) error {
+	return acquire(ctx, m.RetryMin, m.RetryMax, m.TryRLock)
+}
+
+// RUnlock 解读锁，未持有时返回 ErrLockNotHeld
+func (m *RWMutex) RUnlock(ctx context.Context) error {
+	token, err := m.release(false)
+	if err != nil {
+		return err
+	}
+
+	removed, err := Universal(m.Client).ZRem(ctx, m.readKey(), token).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// TryLock 尝试加写锁，不阻塞
+func (m *RWMutex) TryLock(ctx context.Context) (bool, error) {
+	return m.try(ctx, scriptWriteLock, true)
+}
+
+// Lock 阻塞加写锁，直至成功或ctx结束
+func (m *RWMutex) Lock(ctx context.Context) error {
+	return acquire(ctx, m.RetryMin, m.RetryMax, m.TryLock)
+}
+
+// Unlock 解写锁，未持有时返回 ErrLockNotHeld
+func (m *RWMutex) Unlock(ctx context.Context) error {
+	token, err := m.release(true)
+	if err != nil {
+		return err
+	}
+
+	result, err := scriptUnlock.Run(ctx, Universal(m.Client), []string{m.writeKey()}, token).Int64()
+	if err != nil {
+		return err
+	}
+	if result == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// Refresh 续期当前持有的读锁或写锁，锁已过期时返回 ErrLockNotHeld
+func (m *RWMutex) Refresh(ctx context.Context) error {
+	m.mutex.Lock()
+	token, writing := m.token, m.writing
+	m.mutex.Unlock()
+
+	if token == "" {
+		return ErrLockNotHeld
+	}
+
+	var result int64
+	var err error
+	if writing {
+		result, err = scriptRefresh.Run(ctx, Universal(m.Client), []string{m.writeKey()}, token, m.expire().Milliseconds()).Int64()
+	} else {
+		result, err = scriptReadRefresh.Run(ctx, Universal(m.Client), m.keys(), token, m.expire().Milliseconds()).Int64()
+	}
+	if err != nil {
+		return err
+	}
+	if result == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+func (m *RWMutex) try(ctx context.Context, script *redis.Script, writing bool) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.token != "" {
+		return false, nil
+	}
+
+	token := newToken()
+	result, err := script.Run(ctx, Universal(m.Client), m.keys(), token, m.expire().Milliseconds()).Int64()
+	if err != nil || result == 0 {
+		return false, err
+	}
+
+	m.token, m.writing = token, writing
+	return true, nil
+}
+
+// release 清除本地持有状态，返回令牌
+func (m *RWMutex) release(writing bool) (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.token == "" || m.writing != writing {
+		return "", ErrLockNotHeld
+	}
+
+	token := m.token
+	m.token, m.writing = "", false
+
+	return token, nil
+}
+
+// keys 写锁与读者，使用相同的 hash tag 保证集群下位于同一 slot
+func (m *RWMutex) keys() []string {
+	return []string{m.writeKey(), m.readKey()}
+}
+
+func (m *RWMutex) writeKey() string {
+	return NamespaceKey(KeyLock + "{" + m.Name + "}:write")
+}
+
+func (m *RWMutex) readKey() string {
+	return NamespaceKey(KeyLock + "{" + m.Name + "}:read")
+}
+
+func (m *RWMutex) expire() time.Duration {
+	if m.Expire <= 0 {
+		return 30 * time.Second
+	}
+
+	return m.Expire
+}
@@ -0,0 +1,123 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRWMutex 测试多个读者与写者互斥
+func TestRWMutex(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("rw_mutex", Option{Address: []string{s.Addr()}})
+	pool.Delete("rw_mutex")
+
+	ctx := context.Background()
+	newRW := func() *RWMutex {
+		m := NewRWMutex("config", 10*time.Second)
+		m.Client = "rw_mutex"
+		return m
+	}
+	reader1, reader2, writer := newRW(), newRW(), newRW()
+
+	// 多个读者可同时持有
+	if ok, err := reader1.TryRLock(ctx); !ok || err != nil {
+		t.Fatalf("读者1加锁失败: %v", err)
+	}
+	if ok, err := reader2.TryRLock(ctx); !ok || err != nil {
+		t.Fatalf("读者2加锁失败: %v", err)
+	}
+
+	// 有读者时写者失败
+	if ok, _ := writer.TryLock(ctx); ok {
+		t.Fatal("有读者时写者不应该加锁成功")
+	}
+
+	if err := reader1.RUnlock(ctx); err != nil {
+		t.Errorf("读者1解锁失败: %v", err)
+	}
+	if ok, _ := writer.TryLock(ctx); ok {
+		t.Fatal("仍有读者时写者不应该加锁成功")
+	}
+	if err := reader1.RUnlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("重复解锁期望ErrLockNotHeld，实际为%v", err)
+	}
+
+	// 读者阻塞释放后写者获得锁
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		reader2.RUnlock(context.Background())
+	}()
+	writer.RetryMin, writer.RetryMax = 10*time.Millisecond, 20*time.Millisecond
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := writer.Lock(timeoutCtx); err != nil {
+		t.Fatalf("读者释放后写者应该加锁成功: %v", err)
+	}
+
+	// 有写者时读者与其他写者失败
+	if ok, _ := reader1.TryRLock(ctx); ok {
+		t.Error("有写者时读者不应该加锁成功")
+	}
+	if ok, _ := newRW().TryLock(ctx); ok {
+		t.Error("有写者时其他写者不应该加锁成功")
+	}
+	if err := writer.RUnlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("写者解读锁期望ErrLockNotHeld，实际为%v", err)
+	}
+	if err := writer.Refresh(ctx); err != nil {
+		t.Errorf("写者续期失败: %v", err)
+	}
+
+	if err := writer.Unlock(ctx); err != nil {
+		t.Errorf("写者解锁失败: %v", err)
+	}
+	if ok, _ := reader1.TryRLock(ctx); !ok {
+		t.Error("写者释放后读者应该加锁成功")
+	}
+}
+
+// TestRWMutexReaderExpire 测试崩溃的读者过期后不再阻塞写者
+func TestRWMutexReaderExpire(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("rw_mutex_expire", Option{Address: []string{s.Addr()}})
+	pool.Delete("rw_mutex_expire")
+
+	ctx := context.Background()
+	now := time.Now()
+	s.SetTime(now)
+
+	reader := &RWMutex{Name: "expire", Client: "rw_mutex_expire", Expire: 5 * time.Second}
+	longReader := &RWMutex{Name: "expire", Client: "rw_mutex_expire", Expire: 20 * time.Second}
+	writer := &RWMutex{Name: "expire", Client: "rw_mutex_expire"}
+
+	if ok, _ := reader.TryRLock(ctx); !ok {
+		t.Fatal("读者加锁失败")
+	}
+	if ok, _ := longReader.TryRLock(ctx); !ok {
+		t.Fatal("读者加锁失败")
+	}
+
+	// 短读者续期后仍有效
+	s.SetTime(now.Add(4 * time.Second))
+	if err := reader.Refresh(ctx); err != nil {
+		t.Errorf("读者续期失败: %v", err)
+	}
+
+	// 长读者解锁，短读者崩溃未解锁，过期后写者可加锁
+	longReader.RUnlock(ctx)
+	s.SetTime(now.Add(8 * time.Second))
+	if ok, _ := writer.TryLock(ctx); ok {
+		t.Fatal("读者续期后仍有效，写者不应该加锁成功")
+	}
+	s.SetTime(now.Add(10 * time.Second))
+	if ok, _ := writer.TryLock(ctx); !ok {
+		t.Error("读者过期后写者应该加锁成功")
+	}
+	if err := reader.Refresh(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("过期读者续期期望ErrLockNotHeld，实际为%v", err)
+	}
+}
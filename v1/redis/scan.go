@@ -0,0 +1,169 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyScanner 按模式遍历键，使用 SCAN 分批读取，不使用 KEYS 阻塞服务端
+// cluster 模式逐个主节点遍历，单机、sentinel 模式遍历当前库
+// SCAN 可能返回重复的键，遍历期间新增或删除的键不保证返回
+type KeyScanner struct {
+	Client   string        //redis配置名称，留空使用default
+	Match    string        //匹配模式，如 Pattern("user")，必填
+	Type     string        //只返回该类型的键，如 string、hash，默认不限
+	Count    int64         //每批数量提示，默认100
+	Interval time.Duration //每批之间的间隔，用于限速，默认10毫秒，负数不等待
+}
+
+// NewKeyScanner 实例化
+func NewKeyScanner(match string) *KeyScanner {
+	return &KeyScanner{Match: match}
+}
+
+// Each 遍历匹配的键，每批调用一次 handler，handler 返回错误或 ctx 结束时停止
+func (s *KeyScanner) Each(ctx context.Context, handler func(ctx context.Context, keys []string) error) error {
+	if s.Match == "" {
+		return errors.New("redis: key scanner match required")
+	}
+
+	client, err := Get(ctx, s.Client)
+	if err != nil {
+		return err
+	}
+
+	shards, err := s.shards(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	for _, shard := range shards {
+		if err := s.scan(ctx, shard, handler); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete 删除匹配的键，使用 UNLINK 异步释放内存，返回删除数量
+func (s *KeyScanner) Delete(ctx context.Context) (int64, error) {
+	return s.apply(ctx, func(pipe redis.Pipeliner, key string) *redis.IntCmd {
+		return pipe.Unlink(ctx, key)
+	})
+}
+
+// Expire 为匹配的键设置过期时间，expire 需大于0，返回设置成功的数量
+func (s *KeyScanner) Expire(ctx context.Context, expire time.Duration) (int64, error) {
+	if expire <= 0 {
+		return 0, errors.New("redis: key scanner expire must be positive")
+	}
+
+	return s.apply(ctx, func(pipe redis.Pipeliner, key string) *redis.IntCmd {
+		cmd := redis.NewIntCmd(ctx, "pexpire", key, expire.Milliseconds())
+		_ = pipe.Process(ctx, cmd)
+		return cmd
+	})
+}
+
+// apply 逐批通过管道对每个键执行单键命令，cluster 模式按槽位路由，避免跨槽位错误
+func (s *KeyScanner) apply(ctx context.Context, command func(pipe redis.Pipeliner, key string) *redis.IntCmd) (int64, error) {
+	client, err := Get(ctx, s.Client)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	err = s.Each(ctx, func(ctx context.Context, keys []string) error {
+		pipe := client.Pipeline()
+		cmds := make([]*redis.IntCmd, 0, len(keys))
+		for _, key := range keys {
+			cmds = append(cmds, command(pipe, key))
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+
+		for _, cmd := range cmds {
+			total += cmd.Val()
+		}
+		return nil
+	})
+
+	return total, err
+}
+
+// shards 需要遍历的节点，cluster 模式为全部主节点
+func (s *KeyScanner) shards(ctx context.Context, client redis.UniversalClient) ([]redis.UniversalClient, error) {
+	cluster, ok := client.(*redis.ClusterClient)
+	if !ok {
+		return []redis.UniversalClient{client}, nil
+	}
+
+	var shards []redis.UniversalClient
+	var lock sync.Mutex
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+		lock.Lock()
+		defer lock.Unlock()
+		shards = append(shards, master)
+		return nil
+	})
+
+	return shards, err
+}
+
+// scan 遍历单个节点
+func (s *KeyScanner) scan(ctx context.Context, client redis.UniversalClient, handler func(ctx context.Context, keys []string) error) error {
+	var cursor uint64
+	for {
+		var keys []string
+		var err error
+		if s.Type != "" {
+			keys, cursor, err = client.ScanType(ctx, cursor, s.Match, s.count(), s.Type).Result()
+		} else {
+			keys, cursor, err = client.Scan(ctx, cursor, s.Match, s.count()).Result()
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			if err := handler(ctx, keys); err != nil {
+				return err
+			}
+		}
+		if cursor == 0 {
+			return nil
+		}
+
+		if interval := s.interval(); interval > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *KeyScanner) count() int64 {
+	if s.Count <= 0 {
+		return 100
+	}
+
+	return s.Count
+}
+
+func (s *KeyScanner) interval() time.Duration {
+	if s.Interval == 0 {
+		return 10 * time.Millisecond
+	}
+
+	return s.Interval
+}
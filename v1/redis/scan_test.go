@@ -0,0 +1,111 @@
+package redis
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestKeyScanner 测试分批遍历、按类型过滤、批量删除与设置过期
+func TestKeyScanner(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	ctx := context.Background()
+	Add("test_scan", Option{Address: []string{s.Addr()}})
+	t.Cleanup(func() { Close("test_scan") })
+
+	builder := NewKeyBuilder("shop", "test")
+	for i := 0; i < 25; i++ {
+		s.Set(builder.Key("user", strconv.Itoa(i)), "1")
+	}
+	s.HSet(builder.Key("user", "hash"), "field", "1")
+	s.Set(builder.Key("order", "1"), "1")
+
+	scanner := NewKeyScanner(builder.Pattern("user"))
+	scanner.Client = "test_scan"
+	scanner.Count = 10
+	scanner.Interval = -1
+
+	var found []string
+	if err := scanner.Each(ctx, func(ctx context.Context, keys []string) error {
+		found = append(found, keys...)
+		return nil
+	}); err != nil {
+		t.Fatalf("遍历失败: %v", err)
+	}
+	if len(found) != 26 {
+		t.Errorf("期望遍历26个键，实际%d", len(found))
+	}
+
+	scanner.Type = "hash"
+	found = nil
+	_ = scanner.Each(ctx, func(ctx context.Context, keys []string) error {
+		found = append(found, keys...)
+		return nil
+	})
+	if len(found) != 1 || found[0] != "shop:test:user:hash" {
+		t.Errorf("按类型过滤错误 %v", found)
+	}
+	scanner.Type = ""
+
+	// 设置过期
+	if count, err := scanner.Expire(ctx, time.Minute); count != 26 || err != nil {
+		t.Errorf("期望设置26个，实际%d %v", count, err)
+	}
+	if ttl := s.TTL("shop:test:user:1"); ttl != time.Minute {
+		t.Errorf("过期时间错误 %v", ttl)
+	}
+	if _, err := scanner.Expire(ctx, 0); err == nil {
+		t.Error("过期时间不大于0时应该返回错误")
+	}
+
+	// 删除，miniredis 的游标为偏移量，边遍历边删除会跳过键，单批完成
+	scanner.Count = 100
+	if count, err := scanner.Delete(ctx); count != 26 || err != nil {
+		t.Errorf("期望删除26个，实际%d %v", count, err)
+	}
+	keys := s.Keys()
+	sort.Strings(keys)
+	if len(keys) != 1 || keys[0] != "shop:test:order:1" {
+		t.Errorf("不应该删除其他键 %v", keys)
+	}
+
+	if _, err := NewKeyScanner("").Delete(ctx); err == nil {
+		t.Error("未设置匹配模式时应该返回错误")
+	}
+}
+
+// TestKeyScannerCluster 测试 cluster 模式逐个主节点遍历，ctx 结束时停止
+func TestKeyScannerCluster(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	Add("test_scan_cluster", Option{Mode: ModeCluster, Address: []string{s.Addr()}})
+	t.Cleanup(func() { Close("test_scan_cluster") })
+
+	for i := 0; i < 5; i++ {
+		s.Set("cluster:"+strconv.Itoa(i), "1")
+	}
+
+	scanner := NewKeyScanner("cluster:*")
+	scanner.Client = "test_scan_cluster"
+	if count, err := scanner.Delete(context.Background()); count != 5 || err != nil {
+		t.Errorf("期望删除5个，实际%d %v", count, err)
+	}
+
+	for i := 0; i < 5; i++ {
+		s.Set("cluster:"+strconv.Itoa(i), "1")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	scanner.Count = 1
+	var calls int
+	err := scanner.Each(ctx, func(ctx context.Context, keys []string) error {
+		calls++
+		cancel()
+		return nil
+	})
+	if err != context.Canceled || calls != 1 {
+		t.Errorf("ctx 结束时应该停止 %v %d", err, calls)
+	}
+}
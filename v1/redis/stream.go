@@ -0,0 +1,383 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lynnclub/go/v1/logger"
+	"github.com/lynnclub/go/v1/signal"
+	"github.com/redis/go-redis/v9"
+)
+
+// StreamMessage 消息
+type StreamMessage struct {
+	ID       string                 // 消息ID
+	Values   map[string]interface{} // 消息内容
+	Attempts int64                  // 第几次投递，从1开始
+}
+
+// StreamHandler 消息处理，返回 nil 时确认，否则等待重试
+type StreamHandler func(ctx context.Context, message StreamMessage) error
+
+// StreamWorker Redis Streams 消费组
+// 处理失败的消息留在待确认列表，空闲超过 MinIdle 后被认领重试，包括已下线消费者的消息
+// 投递次数超过 MaxAttempts 后转入死信队列
+type StreamWorker struct {
+	Client        string        // redis配置名称，留空使用default
+	Stream        string        // 流
+	Group         string        // 消费组，不存在时自动创建
+	Consumer      string        // 消费者名称，默认 主机名-进程号
+	StartID       string        // 创建消费组的起始ID，默认0，即从头消费
+	Concurrency   int           // 并发数，默认10
+	Block         time.Duration // 读取阻塞时间，默认2秒，也是停止的最长等待时间
+	MinIdle       time.Duration // 空闲多久视为处理失败或消费者下线，默认1分钟
+	ClaimInterval time.Duration // 认领间隔，默认 MinIdle/2
+	MaxAttempts   int64         // 最大投递次数，默认5
+	DeadLetter    string        // 死信队列，默认 Stream+":dead"
+	Handler       StreamHandler // 消息处理
+
+	wait sync.WaitGroup
+	sem  chan struct{}
+}
+
+// NewStreamWorker 消费组实例化
+func NewStreamWorker(stream, group string, handler StreamHandler) *StreamWorker {
+	return &StreamWorker{Stream: stream, Group: group, Handler: handler}
+}
+
+// Run 运行，阻塞直至ctx结束或收到 signal.Listen 监听的信号，等待处理中的消息完成后返回
+func (w *StreamWorker) Run(ctx context.Context) error {
+	if w.Stream == "" || w.Group == "" || w.Handler == nil {
+		return errors.New("redis: stream worker stream, group and handler required")
+	}
+
+	ctx, cancel := signal.Context(ctx)
+	defer cancel()
+
+	if err := w.createGroup(ctx); err != nil {
+		return err
+	}
+
+	w.sem = make(chan struct{}, w.concurrency())
+	if w.Consumer == "" {
+		hostname, _ := os.Hostname()
+		w.Consumer = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+
+	var loops sync.WaitGroup
+	loops.Add(2)
+	go func() {
+		defer loops.Done()
+		w.readLoop(ctx)
+	}()
+	go func() {
+		defer loops.Done()
+		w.claimLoop(ctx)
+	}()
+
+	loops.Wait()
+	w.wait.Wait()
+
+	return nil
+}
+
+// createGroup 创建消费组，已存在时忽略
+func (w *StreamWorker) createGroup(ctx context.Context) error {
+	startID := w.StartID
+	if startID == "" {
+		startID = "0"
+	}
+
+	client, err := Get(ctx, w.Client)
+	if err != nil {
+		return err
+	}
+
+	err = client.XGroupCreateMkStream(ctx, w.Stream, w.Group, startID).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	return nil
+}
+
+// readLoop 读取新消息
+func (w *StreamWorker) readLoop(ctx context.Context) {
+	for ctx.Err() == nil {
+		// 有空闲名额时才读取，避免消息积压在本地，按空闲名额批量读取
+		if !w.acquire(ctx) {
+			return
+		}
+		held := true
+
+		// 每次重新获取，Reload 后使用新连接，连接失败按读取失败重试
+		var streams []redis.XStream
+		client, err := Get(ctx, w.Client)
+		if err == nil {
+			streams, err = client.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    w.Group,
+				Consumer: w.Consumer,
+				Streams:  []string{w.Stream, ">"},
+				Count:    int64(1 + w.idle()),
+				Block:    w.block(),
+			}).Result()
+		}
+		if err != nil {
+			<-w.sem
+			if errors.Is(err, Nil) || ctx.Err() != nil {
+				continue
+			}
+
+			// 消费组被删除时重建
+			if strings.HasPrefix(err.Error(), "NOGROUP") {
+				err = w.createGroup(ctx)
+			}
+			if err != nil {
+				logger.Error("stream读取失败", w.Stream, err.Error())
+				w.sleep(ctx, time.Second)
+			}
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, message := range stream.Messages {
+				if !held && !w.acquire(ctx) {
+					// 已读取未处理的消息留在待确认列表，由认领重试
+					return
+				}
+				held = false
+				w.handle(ctx, StreamMessage{ID: message.ID, Values: message.Values, Attempts: 1})
+			}
+		}
+		if held {
+			<-w.sem
+		}
+	}
+}
+
+// claimLoop 认领空闲过久的消息，投递次数超限时转入死信队列
+func (w *StreamWorker) claimLoop(ctx context.Context) {
+	ticker := time.NewTicker(w.claimInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.claim(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("stream认领失败", w.Stream, err.Error())
+			}
+		}
+	}
+}
+
+// claim 认领一轮，XAUTOCLAIM 会增加投递次数，通过 XPENDING 读取
+func (w *StreamWorker) claim(ctx context.Context) error {
+	client, err := Get(ctx, w.Client)
+	if err != nil {
+		return err
+	}
+
+	start := "0-0"
+	for ctx.Err() == nil {
+		messages, next, err := client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   w.Stream,
+			Group:    w.Group,
+			Consumer: w.Consumer,
+			MinIdle:  w.minIdle(),
+			Start:    start,
+			Count:    int64(w.concurrency()),
+		}).Result()
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			attempts, err := w.attempts(ctx, message.ID)
+			if err != nil {
+				return err
+			}
+			if attempts == 0 {
+				// 认领后已被确认
+				continue
+			}
+
+			if attempts > w.maxAttempts() {
+				if err = w.deadLetter(ctx, message, attempts); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if !w.acquire(ctx) {
+				return nil
+			}
+			w.handle(ctx, StreamMessage{ID: message.ID, Values: message.Values, Attempts: attempts})
+		}
+
+		if next == "0-0" || next == "" {
+			return nil
+		}
+		start = next
+	}
+
+	return nil
+}
+
+// attempts 投递次数
+func (w *StreamWorker) attempts(ctx context.Context, id string) (int64, error) {
+	client, err := Get(ctx, w.Client)
+	if err != nil {
+		return 0, err
+	}
+
+	pending, err := client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: w.Stream,
+		Group:  w.Group,
+		Start:  id,
+		End:    id,
+		Count:  1,
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	return pending[0].RetryCount, nil
+}
+
+// deadLetter 转入死信队列并确认，保留原消息ID与投递次数
+// 死信队列与原队列在 cluster 模式下可能位于不同槽位，不能使用事务，写入成功后再确认，确认失败时可能重复转入
+func (w *StreamWorker) deadLetter(ctx context.Context, message redis.XMessage, attempts int64) error {
+	values := make(map[string]interface{}, len(message.Values)+3)
+	for key, value := range message.Values {
+		values[key] = value
+	}
+	values["_stream"] = w.Stream
+	values["_id"] = message.ID
+	values["_attempts"] = attempts
+
+	client, err := Get(ctx, w.Client)
+	if err != nil {
+		return err
+	}
+
+	if err := client.XAdd(ctx, &redis.XAddArgs{Stream: w.deadLetterStream(), Values: values}).Err(); err != nil {
+		return err
+	}
+
+	return client.XAck(ctx, w.Stream, w.Group, message.ID).Err()
+}
+
+// handle 异步处理，成功时确认；调用前需已占用并发名额
+func (w *StreamWorker) handle(ctx context.Context, message StreamMessage) {
+	w.wait.Add(1)
+	go func() {
+		defer w.wait.Done()
+		defer func() { <-w.sem }()
+
+		// 停止时等待处理完成，不中断处理中的消息
+		handleCtx := context.WithoutCancel(ctx)
+		if err := w.call(handleCtx, message); err != nil {
+			logger.Warn("stream消息处理失败", w.Stream, message.ID, message.Attempts, err.Error())
+			return
+		}
+
+		client, err := Get(handleCtx, w.Client)
+		if err == nil {
+			err = client.XAck(handleCtx, w.Stream, w.Group, message.ID).Err()
+		}
+		if err != nil {
+			logger.Error("stream消息确认失败", w.Stream, message.ID, err.Error())
+		}
+	}()
+}
+
+// call 调用处理函数，panic 视为处理失败
+func (w *StreamWorker) call(ctx context.Context, message StreamMessage) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return w.Handler(ctx, message)
+}
+
+// acquire 占用并发名额，ctx结束时返回 false
+func (w *StreamWorker) acquire(ctx context.Context) bool {
+	select {
+	case w.sem <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// idle 空闲名额
+func (w *StreamWorker) idle() int {
+	return cap(w.sem) - len(w.sem)
+}
+
+func (w *StreamWorker) sleep(ctx context.Context, duration time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(duration):
+	}
+}
+
+func (w *StreamWorker) concurrency() int {
+	if w.Concurrency <= 0 {
+		return 10
+	}
+
+	return w.Concurrency
+}
+
+func (w *StreamWorker) block() time.Duration {
+	if w.Block <= 0 {
+		return 2 * time.Second
+	}
+
+	return w.Block
+}
+
+func (w *StreamWorker) minIdle() time.Duration {
+	if w.MinIdle <= 0 {
+		return time.Minute
+	}
+
+	return w.MinIdle
+}
+
+func (w *StreamWorker) claimInterval() time.Duration {
+	if w.ClaimInterval <= 0 {
+		return w.minIdle() / 2
+	}
+
+	return w.ClaimInterval
+}
+
+func (w *StreamWorker) maxAttempts() int64 {
+	if w.MaxAttempts <= 0 {
+		return 5
+	}
+
+	return w.MaxAttempts
+}
+
+func (w *StreamWorker) deadLetterStream() string {
+	if w.DeadLetter == "" {
+		return w.Stream + ":dead"
+	}
+
+	return w.DeadLetter
+}
@@ -0,0 +1,266 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// runWorker 后台运行，返回停止函数，停止时等待 Run 返回
+func runWorker(t *testing.T, worker *StreamWorker) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- worker.Run(ctx)
+	}()
+
+	return func() {
+		cancel()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Run 返回错误: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run 未停止")
+		}
+	}
+}
+
+func waitUntil(t *testing.T, condition func() bool, message string) {
+	for i := 0; i < 200; i++ {
+		if condition() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal(message)
+}
+
+// TestStreamWorker 测试并发消费与确认
+func TestStreamWorker(t *testing.T) {
+	setupRedis(t, "stream_ok")
+	ctx := context.Background()
+	client := Use("stream_ok")
+
+	for i := 0; i < 20; i++ {
+		client.XAdd(ctx, &redis.XAddArgs{Stream: "orders", Values: map[string]interface{}{"id": i}})
+	}
+
+	var handled, running, maxRunning atomic.Int32
+	worker := NewStreamWorker("orders", "billing", func(ctx context.Context, message StreamMessage) error {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := maxRunning.Load()
+			if current <= old || maxRunning.CompareAndSwap(old, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		if message.Attempts != 1 || message.Values["id"] == nil {
+			t.Errorf("消息错误 %+v", message)
+		}
+		handled.Add(1)
+		return nil
+	})
+	worker.Client = "stream_ok"
+	worker.Concurrency = 3
+	worker.Block = 50 * time.Millisecond
+
+	stop := runWorker(t, worker)
+	waitUntil(t, func() bool { return handled.Load() == 20 }, "消息未全部处理")
+	stop()
+
+	if maxRunning.Load() > 3 {
+		t.Errorf("并发数超过3，实际为%d", maxRunning.Load())
+	}
+	if pending, _ := client.XPending(ctx, "orders", "billing").Result(); pending.Count != 0 {
+		t.Errorf("期望全部确认，仍有%d条待确认", pending.Count)
+	}
+}
+
+// TestStreamWorkerReconnect 测试连接被移除后重新获取连接，继续消费
+func TestStreamWorkerReconnect(t *testing.T) {
+	setupRedis(t, "stream_reconnect")
+	ctx := context.Background()
+
+	var handled atomic.Int32
+	worker := NewStreamWorker("events", "sync", func(ctx context.Context, message StreamMessage) error {
+		handled.Add(1)
+		return nil
+	})
+	worker.Client = "stream_reconnect"
+	worker.Block = 20 * time.Millisecond
+
+	stop := runWorker(t, worker)
+	defer stop()
+	time.Sleep(50 * time.Millisecond)
+
+	// 模拟 Reload，旧连接关闭后读取应通过 Get 重新建立连接
+	Close("stream_reconnect")
+	Use("stream_reconnect").XAdd(ctx, &redis.XAddArgs{Stream: "events", Values: map[string]interface{}{"id": 1}})
+
+	deadline := time.Now().Add(3 * time.Second)
+	for handled.Load() != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if handled.Load() != 1 {
+		t.Error("重新连接后应该继续消费")
+	}
+}
+
+// TestStreamWorkerRedisDown 测试运行中 Redis 不可用时按读取失败重试，不panic，可正常停止
+func TestStreamWorkerRedisDown(t *testing.T) {
+	s := setupRedis(t, "stream_down")
+
+	worker := NewStreamWorker("events", "sync", func(ctx context.Context, message StreamMessage) error {
+		return nil
+	})
+	worker.Client = "stream_down"
+	worker.Block = 20 * time.Millisecond
+	worker.ClaimInterval = 20 * time.Millisecond
+
+	stop := runWorker(t, worker)
+	time.Sleep(50 * time.Millisecond)
+
+	// 连接被移除后重新连接失败，读取与认领均不应panic
+	s.Close()
+	Close("stream_down")
+	time.Sleep(100 * time.Millisecond)
+	stop()
+}
+
+// TestStreamWorkerRetry 测试失败重试，投递次数超限后转入死信队列
+func TestStreamWorkerRetry(t *testing.T) {
+	setupRedis(t, "stream_retry")
+	ctx := context.Background()
+	client := Use("stream_retry")
+
+	client.XAdd(ctx, &redis.XAddArgs{Stream: "jobs", Values: map[string]interface{}{"name": "flaky"}})
+	client.XAdd(ctx, &redis.XAddArgs{Stream: "jobs", Values: map[string]interface{}{"name": "broken"}})
+
+	var mutex sync.Mutex
+	attempts := map[string][]int64{}
+	worker := NewStreamWorker("jobs", "runner", func(ctx context.Context, message StreamMessage) error {
+		name := message.Values["name"].(string)
+		mutex.Lock()
+		attempts[name] = append(attempts[name], message.Attempts)
+		mutex.Unlock()
+
+		if name == "flaky" && message.Attempts < 2 {
+			return errors.New("temporary")
+		}
+		if name == "broken" {
+			panic("always fail")
+		}
+		return nil
+	})
+	worker.Client = "stream_retry"
+	worker.Block = 20 * time.Millisecond
+	worker.MinIdle = 50 * time.Millisecond
+	worker.ClaimInterval = 20 * time.Millisecond
+	worker.MaxAttempts = 3
+
+	stop := runWorker(t, worker)
+	waitUntil(t, func() bool {
+		length, _ := client.XLen(ctx, "jobs:dead").Result()
+		return length == 1
+	}, "未转入死信队列")
+	stop()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(attempts["flaky"]) != 2 || attempts["flaky"][1] != 2 {
+		t.Errorf("期望第2次成功，实际投递 %v", attempts["flaky"])
+	}
+	if len(attempts["broken"]) != 3 {
+		t.Errorf("期望投递3次，实际投递 %v", attempts["broken"])
+	}
+
+	dead, _ := client.XRange(ctx, "jobs:dead", "-", "+").Result()
+	if dead[0].Values["name"] != "broken" || dead[0].Values["_attempts"] != "4" || dead[0].Values["_stream"] != "jobs" {
+		t.Errorf("死信消息错误 %+v", dead[0].Values)
+	}
+	if pending, _ := client.XPending(ctx, "jobs", "runner").Result(); pending.Count != 0 {
+		t.Errorf("期望全部确认，仍有%d条待确认", pending.Count)
+	}
+}
+
+// TestStreamWorkerClaim 测试认领已下线消费者的消息
+func TestStreamWorkerClaim(t *testing.T) {
+	setupRedis(t, "stream_claim")
+	ctx := context.Background()
+	client := Use("stream_claim")
+
+	// 下线的消费者读取后未确认
+	client.XGroupCreateMkStream(ctx, "events", "audit", "0")
+	client.XAdd(ctx, &redis.XAddArgs{Stream: "events", Values: map[string]interface{}{"type": "login"}})
+	client.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "audit", Consumer: "dead", Streams: []string{"events", ">"}, Count: 1})
+
+	var claimed atomic.Int64
+	worker := NewStreamWorker("events", "audit", func(ctx context.Context, message StreamMessage) error {
+		claimed.Store(message.Attempts)
+		return nil
+	})
+	worker.Client = "stream_claim"
+	worker.Consumer = "alive"
+	worker.Block = 20 * time.Millisecond
+	worker.MinIdle = 30 * time.Millisecond
+	worker.ClaimInterval = 10 * time.Millisecond
+
+	stop := runWorker(t, worker)
+	waitUntil(t, func() bool { return claimed.Load() > 0 }, "未认领下线消费者的消息")
+	stop()
+
+	if claimed.Load() != 2 {
+		t.Errorf("期望第2次投递，实际为%d", claimed.Load())
+	}
+}
+
+// TestStreamWorkerGracefulStop 测试停止时等待处理中的消息完成
+func TestStreamWorkerGracefulStop(t *testing.T) {
+	setupRedis(t, "stream_stop")
+	ctx := context.Background()
+	client := Use("stream_stop")
+
+	client.XAdd(ctx, &redis.XAddArgs{Stream: "mail", Values: map[string]interface{}{"to": "lynn"}})
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+	worker := NewStreamWorker("mail", "sender", func(ctx context.Context, message StreamMessage) error {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		if ctx.Err() != nil {
+			t.Error("处理中的消息不应该被取消")
+		}
+		finished.Store(true)
+		return nil
+	})
+	worker.Client = "stream_stop"
+	worker.Block = 20 * time.Millisecond
+
+	stop := runWorker(t, worker)
+	<-started
+	stop()
+
+	if !finished.Load() {
+		t.Error("Run 应该等待处理中的消息完成")
+	}
+	if pending, _ := client.XPending(ctx, "mail", "sender").Result(); pending.Count != 0 {
+		t.Errorf("期望已确认，仍有%d条待确认", pending.Count)
+	}
+}
+
+// TestStreamWorkerInvalid 测试缺少配置
+func TestStreamWorkerInvalid(t *testing.T) {
+	if err := (&StreamWorker{}).Run(context.Background()); err == nil {
+		t.Error("期望返回错误")
+	}
+}
@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+)
+
+// newTLSConfig 按配置生成TLS配置，未启用时返回nil
+// 每次新建连接时读取证书文件，证书轮换后 Reload 即可生效
+func newTLSConfig(option Option) (*tls.Config, error) {
+	if !option.TLS {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         option.TLSServerName,
+		InsecureSkipVerify: option.InsecureSkipVerify,
+	}
+
+	if option.TLSCertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(option.TLSCertFile, option.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	if option.TLSCAFile != "" {
+		ca, err := os.ReadFile(option.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificate found in " + option.TLSCAFile)
+		}
+		config.RootCAs = roots
+	}
+
+	return config, nil
+}
@@ -0,0 +1,156 @@
+package redis
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// testCertificate 签发证书，parent 为空时自签名，返回证书、私钥与证书文件、私钥文件路径
+func testCertificate(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{"localhost"},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, _ := x509.ParseCertificate(der)
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	_ = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+
+	return certificate, key, certFile, keyFile
+}
+
+// TestTLS 测试CA、客户端证书与ACL用户名
+func TestTLS(t *testing.T) {
+	ca, caKey, caFile, _ := testCertificate(t, "ca", nil, nil)
+	_, _, serverCert, serverKey := testCertificate(t, "server", ca, caKey)
+	_, _, clientCert, clientKey := testCertificate(t, "client", ca, caKey)
+
+	certificate, err := tls.LoadX509KeyPair(serverCert, serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	s, err := miniredis.RunTLS(&tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientCAs:    roots,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	if err != nil {
+		t.Fatalf("无法启动miniredis: %v", err)
+	}
+	defer s.Close()
+	s.RequireUserAuth("app", "secret")
+	ctx := context.Background()
+
+	AddMap("test_tls", map[string]interface{}{
+		"address":         []interface{}{s.Addr()},
+		"username":        "app",
+		"password":        "secret",
+		"tls_cert_file":   clientCert,
+		"tls_key_file":    clientKey,
+		"tls_ca_file":     caFile,
+		"tls_server_name": "localhost",
+		"dial_timeout":    "1s",
+	})
+	t.Cleanup(func() { Close("test_tls") })
+	if !options["test_tls"].TLS {
+		t.Error("设置证书时应该启用TLS")
+	}
+	client, err := Get(ctx, "test_tls")
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	if err := client.Set(ctx, "key", "value", 0).Err(); err != nil {
+		t.Errorf("执行命令失败: %v", err)
+	}
+
+	// 缺少客户端证书
+	Add("test_tls_no_cert", Option{Address: []string{s.Addr()}, Username: "app", Password: "secret", TLSCAFile: caFile, MaxRetries: -1})
+	if _, err := Get(ctx, "test_tls_no_cert"); err == nil {
+		t.Error("缺少客户端证书时应该连接失败")
+	}
+
+	// 不校验服务端证书，但用户名错误
+	Add("test_tls_user", Option{
+		Address: []string{s.Addr()}, Username: "other", Password: "secret",
+		TLSCertFile: clientCert, TLSKeyFile: clientKey, InsecureSkipVerify: true,
+	})
+	if _, err := Get(ctx, "test_tls_user"); err == nil {
+		t.Error("用户名错误时应该连接失败")
+	}
+
+	// 证书文件不存在
+	Add("test_tls_missing", Option{Address: []string{s.Addr()}, TLSCAFile: filepath.Join(t.TempDir(), "missing.crt")})
+	if _, err := Get(ctx, "test_tls_missing"); err == nil {
+		t.Error("证书文件不存在时应该返回错误")
+	}
+}
+
+// TestTimeoutAndRetry 测试超时与重试配置
+func TestTimeoutAndRetry(t *testing.T) {
+	AddMap("test_timeout", map[string]interface{}{
+		"address":           []interface{}{"localhost:6379"},
+		"dial_timeout":      2,
+		"read_timeout":      "500ms",
+		"write_timeout":     -1,
+		"max_retries":       -5,
+		"min_retry_backoff": 10,
+		"max_retry_backoff": "1s",
+	})
+
+	option := options["test_timeout"]
+	if option.DialTimeout != 2*time.Second || option.ReadTimeout != 500*time.Millisecond || option.WriteTimeout != -1 {
+		t.Errorf("超时配置错误 %+v", option)
+	}
+	if option.MaxRetries != -1 || option.MinRetryBackoff != 10*time.Millisecond || option.MaxRetryBackoff != time.Second {
+		t.Errorf("重试配置错误 %+v", option)
+	}
+	if option.TLS {
+		t.Error("未设置证书时不应该启用TLS")
+	}
+
+	// 证书与私钥需同时设置
+	if _, err := normalize("test_cert_only", Option{Address: []string{"localhost:6379"}, TLSCertFile: "client.crt"}); err == nil {
+		t.Error("只设置证书时应该返回错误")
+	}
+}
@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lynnclub/go/v1/datetime"
+)
+
+// TestUV 测试按天计数与合并统计
+func TestUV(t *testing.T) {
+	s := setupMiniRedis(t)
+	defer s.Close()
+	ctx := context.Background()
+	Add("test_uv", Option{Address: []string{s.Addr()}})
+	t.Cleanup(func() { Close("test_uv") })
+
+	uv := NewUV("home", "Asia/Shanghai")
+	uv.Client = "test_uv"
+
+	if changed, err := uv.Add(ctx, "user:1", "user:2", "user:3"); !changed || err != nil {
+		t.Fatalf("添加失败 %v %v", changed, err)
+	}
+	if changed, _ := uv.Add(ctx, "user:1"); changed {
+		t.Error("重复访客不应该使计数变化")
+	}
+	if count, err := uv.Today(ctx); count != 3 || err != nil {
+		t.Errorf("期望当天3人，实际%d %v", count, err)
+	}
+
+	today := datetime.Date("Asia/Shanghai")
+	if ttl := s.TTL(uv.key(today)); ttl != 31*24*time.Hour {
+		t.Errorf("过期时间错误 %v", ttl)
+	}
+
+	// miniredis 多个键时为各键之和，redis 为合并去重，此处使用不重复的访客
+	_, _ = uv.AddDate(ctx, "2020-01-01", "user:4", "user:5")
+	if count, _ := uv.Count(ctx, "2020-01-01"); count != 2 {
+		t.Errorf("期望指定日期2人，实际%d", count)
+	}
+	if count, _ := uv.Count(ctx, today, "2020-01-01"); count != 5 {
+		t.Errorf("期望合并后5人，实际%d", count)
+	}
+	if count, _ := uv.Count(ctx); count != 0 {
+		t.Error("没有日期时应该为0")
+	}
+}
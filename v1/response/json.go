@@ -16,8 +16,13 @@ type JsonStruct interface {
 // JsonContext 内容
 var JsonContext JsonStruct = &json_struct.Default{}
 
-// Json 响应json
+// Json 响应json，http 状态码固定为200，状态码放在json中
 func Json(c *gin.Context, status int, msg string, data ...interface{}) {
+	JsonCode(c, http.StatusOK, status, msg, data...)
+}
+
+// JsonCode 响应json，指定 http 状态码，用于健康检查等依赖 http 状态码的场景
+func JsonCode(c *gin.Context, code int, status int, msg string, data ...interface{}) {
 	//if msg != "" {
 	//	//多语言、语料编码
 	//	if lang, ok := c.Get("Lang"); ok {
@@ -35,6 +40,6 @@ func Json(c *gin.Context, status int, msg string, data ...interface{}) {
 	JsonContext.Set(status, msg, newData, time.Now().Unix())
 
 	// jsonp键名为callback
-	c.JSONP(http.StatusOK, JsonContext)
+	c.JSONP(code, JsonContext)
 	c.Abort()
 }
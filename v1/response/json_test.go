@@ -381,3 +381,30 @@ func TestJsonEmptyMessage(t *testing.T) {
 		t.Errorf("期望msg为空字符串，实际%v", response["msg"])
 	}
 }
+
+// TestJsonCode 测试指定http状态码
+func TestJsonCode(t *testing.T) {
+	router := setupTestRouter()
+
+	router.GET("/test", func(c *gin.Context) {
+		JsonCode(c, http.StatusServiceUnavailable, 503, "not ready", map[string]string{"redis": "down"})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("期望状态码503，实际%d", w.Code)
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if int(response["status"].(float64)) != 503 {
+		t.Errorf("期望status为503，实际%v", response["status"])
+	}
+	if response["msg"] != "not ready" {
+		t.Errorf("期望msg为not ready，实际%v", response["msg"])
+	}
+}
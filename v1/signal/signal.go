@@ -1,8 +1,10 @@
 package signal
 
 import (
+	"context"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 )
 
@@ -12,6 +14,11 @@ var Now os.Signal
 // ChannelOS 系统信号
 var ChannelOS = make(chan os.Signal, 1)
 
+var (
+	done     = make(chan struct{})
+	doneOnce sync.Once
+)
+
 // Listen 监听
 // SIGHUP 挂起（hangup），当终端关闭或者连接的会话结束时，由内核发送给进程
 // SIGINT 中断（interrupt），通常由用户按下 Ctrl+C 产生，进程接收到信号后应立即停止当前的工作
@@ -28,8 +35,28 @@ func Listen(signals ...os.Signal) {
 
 	go func(ch chan os.Signal) {
 		Now = <-ch
+		doneOnce.Do(func() { close(done) })
 		close(ch)
 	}(ChannelOS)
 
 	signal.Notify(ChannelOS, signals...)
 }
+
+// Done 收到信号后关闭，可被多处同时监听，需先调用 Listen
+func Done() <-chan struct{} {
+	return done
+}
+
+// Context 收到信号或 parent 结束时取消，用于常驻协程平滑退出
+func Context(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
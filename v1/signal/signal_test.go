@@ -1,6 +1,7 @@
 package signal
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"syscall"
@@ -63,3 +64,35 @@ func TestListen(t *testing.T) {
 	// 	break
 	// }
 }
+
+// TestContext 信号上下文
+func TestContext(t *testing.T) {
+	// parent 结束时取消
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel := Context(parent)
+	defer cancel()
+	cancelParent()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("context not canceled after parent canceled")
+	}
+
+	// TestListen 已收到信号
+	if Now == nil {
+		t.Skip("signal not received")
+	}
+	select {
+	case <-Done():
+	default:
+		t.Error("Done not closed after signal")
+	}
+
+	ctx, cancel = Context(context.Background())
+	defer cancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("context not canceled after signal")
+	}
+}